@@ -0,0 +1,45 @@
+// Package agent is the dev agent that podflow uploads to a project Pod. It
+// prepares the Python environment and runs the project's API server,
+// restarting it whenever podflow syncs changes, and reports what it is doing
+// as JSON events on stdout.
+//
+// The CLI and the agent share the types in this file; the agent itself only
+// builds for Linux.
+package agent
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Config describes the project on the Pod. It is written as JSON by the CLI
+// and read by the agent with -config.
+type Config struct {
+	ProjectDir       string   `json:"project_dir"`
+	VenvPath         string   `json:"venv_path"`
+	ArchivedVenvPath string   `json:"archived_venv_path"`
+	RequirementsPath string   `json:"requirements_path"` // relative to ProjectDir
+	HandlerPath      string   `json:"handler_path"`      // relative to ProjectDir
+	PackageManager   string   `json:"package_manager"`   // "uv" or "pip"
+	PythonVersion    string   `json:"python_version"`
+	APIPort          int      `json:"api_port"`
+	TriggerFile      string   `json:"trigger_file"` // rewritten by the CLI after every sync
+	Packages         []string `json:"packages"`     // extra apt packages to install if missing
+}
+
+// LoadConfig reads a Config written by the CLI.
+func LoadConfig(file string) (*Config, error) {
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	var cfg Config
+	if err := json.Unmarshal(data, &cfg); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", file, err)
+	}
+	if cfg.ProjectDir == "" || cfg.VenvPath == "" {
+		return nil, fmt.Errorf("%s: project_dir and venv_path are required", file)
+	}
+	return &cfg, nil
+}
@@ -0,0 +1,91 @@
+package agent
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// Event types.
+const (
+	EventStatus = "status" // a step of the agent's work, in Message
+	EventLog    = "log"    // a line of output from a process the agent runs
+	EventError  = "error"  // a failure the agent survived or is exiting with
+)
+
+// Event is one line of the agent's output.
+type Event struct {
+	Type    string `json:"type"`
+	Message string `json:"message"`
+	Stream  string `json:"stream,omitempty"` // "stdout" or "stderr" for log events
+	PID     int    `json:"pid,omitempty"`    // of the API server, for status events about it
+}
+
+// ParseEvent decodes a line of agent output, reporting false for anything
+// that is not an event, such as output from before the agent started.
+func ParseEvent(line string) (Event, bool) {
+	var event Event
+	if err := json.Unmarshal([]byte(line), &event); err != nil || event.Type == "" {
+		return Event{}, false
+	}
+	return event, true
+}
+
+// Emitter writes events, one JSON object per line.
+type Emitter struct {
+	mu  sync.Mutex
+	enc *json.Encoder
+}
+
+func NewEmitter(w io.Writer) *Emitter {
+	return &Emitter{enc: json.NewEncoder(w)}
+}
+
+func (e *Emitter) emit(event Event) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.enc.Encode(event)
+}
+
+func (e *Emitter) Status(format string, args ...interface{}) {
+	e.emit(Event{Type: EventStatus, Message: fmt.Sprintf(format, args...)})
+}
+
+func (e *Emitter) serverStatus(pid int, format string, args ...interface{}) {
+	e.emit(Event{Type: EventStatus, Message: fmt.Sprintf(format, args...), PID: pid})
+}
+
+func (e *Emitter) Error(err error) {
+	e.emit(Event{Type: EventError, Message: err.Error()})
+}
+
+// Output returns a writer that emits each line written to it as a log event.
+// Closing it flushes a final line without a newline.
+func (e *Emitter) Output(stream string) io.WriteCloser {
+	r, w := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			e.emit(Event{Type: EventLog, Message: scanner.Text(), Stream: stream})
+		}
+		// Keep draining so a long line never blocks the process writing it.
+		io.Copy(io.Discard, r)
+	}()
+	return &outputWriter{PipeWriter: w, done: done}
+}
+
+type outputWriter struct {
+	*io.PipeWriter
+	done chan struct{}
+}
+
+func (w *outputWriter) Close() error {
+	err := w.PipeWriter.Close()
+	<-w.done
+	return err
+}
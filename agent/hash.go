@@ -0,0 +1,83 @@
+package agent
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// ChunkSize is the block size the builtin sync engine compares files in.
+const ChunkSize = 64 << 10
+
+// ChunkHashes returns the hex SHA-256 of every ChunkSize block of the file
+// name, so that a copy elsewhere can be patched by sending only the blocks
+// whose hashes differ.
+func ChunkHashes(name string) ([]string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var chunks []string
+	buf := make([]byte, ChunkSize)
+	for {
+		n, err := io.ReadFull(f, buf)
+		if n > 0 {
+			sum := sha256.Sum256(buf[:n])
+			chunks = append(chunks, hex.EncodeToString(sum[:]))
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return chunks, nil
+		} else if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// WriteChunkHashes writes the chunk hashes of each of paths to w as lines of
+// "<index> <hash> <path>", the format the CLI reads with ParseChunkHashes.
+// Files that cannot be read are left out rather than failing the rest.
+func WriteChunkHashes(w io.Writer, paths []string) error {
+	out := bufio.NewWriter(w)
+	for _, p := range paths {
+		chunks, err := ChunkHashes(p)
+		if err != nil {
+			continue
+		}
+		for i, sum := range chunks {
+			fmt.Fprintf(out, "%d %s %s\n", i, sum, p)
+		}
+	}
+	return out.Flush()
+}
+
+// ParseChunkHashes reads the output of WriteChunkHashes, or of the shell
+// script that stands in for it, into the chunk hashes of each path. A path
+// whose chunks are not all listed in order is left out.
+func ParseChunkHashes(r io.Reader) (map[string][]string, error) {
+	hashes := map[string][]string{}
+	broken := map[string]bool{}
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		index, rest, _ := strings.Cut(scanner.Text(), " ")
+		sum, p, ok := strings.Cut(rest, " ")
+		i, err := strconv.Atoi(index)
+		if !ok || err != nil || len(sum) != sha256.Size*2 {
+			continue
+		}
+		if i != len(hashes[p]) {
+			broken[p] = true
+		}
+		hashes[p] = append(hashes[p], sum)
+	}
+	for p := range broken {
+		delete(hashes, p)
+	}
+	return hashes, scanner.Err()
+}
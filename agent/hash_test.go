@@ -0,0 +1,30 @@
+package agent
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseChunkHashes(t *testing.T) {
+	a := strings.Repeat("a", 64)
+	b := strings.Repeat("b", 64)
+	input := strings.Join([]string{
+		"0 " + a + " /p/one file.py",
+		"1 " + b + " /p/one file.py",
+		"0 " + a + " /p/gap",
+		"2 " + b + " /p/gap",
+		"0 short /p/bad",
+		"x " + a + " /p/bad",
+		"a stray line",
+		"",
+	}, "\n")
+	got, err := ParseChunkHashes(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]string{"/p/one file.py": {a, b}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseChunkHashes = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,51 @@
+//go:build linux
+
+// Command podflow-agent runs on a project Pod on behalf of 'podflow dev'.
+// It is built for Linux and embedded in the CLI, which uploads it over SSH.
+//
+//	podflow-agent setup -config FILE   install packages, restore the venv, install requirements
+//	podflow-agent serve -config FILE   run the API server, restarting it after every sync
+//	podflow-agent archive -config FILE store the venv on the network volume
+//	podflow-agent hash FILE...         print the hashes of the files' sync chunks
+package main
+
+import (
+	"cli/agent"
+	"flag"
+	"fmt"
+	"os"
+)
+
+func main() {
+	if len(os.Args) >= 2 && os.Args[1] == "hash" {
+		if err := agent.WriteChunkHashes(os.Stdout, os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	commands := map[string]func(*agent.Config, *agent.Emitter) error{
+		"setup":   agent.Setup,
+		"serve":   agent.Serve,
+		"archive": agent.Archive,
+	}
+	if len(os.Args) < 2 || commands[os.Args[1]] == nil {
+		fmt.Fprintln(os.Stderr, "usage: podflow-agent setup|serve|archive -config FILE, or podflow-agent hash FILE...")
+		os.Exit(2)
+	}
+	command := os.Args[1]
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	configFile := flags.String("config", "", "project configuration written by podflow")
+	flags.Parse(os.Args[2:])
+
+	events := agent.NewEmitter(os.Stdout)
+	cfg, err := agent.LoadConfig(*configFile)
+	if err == nil {
+		err = commands[command](cfg, events)
+	}
+	if err != nil {
+		events.Error(err)
+		os.Exit(1)
+	}
+}
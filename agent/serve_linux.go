@@ -0,0 +1,178 @@
+//go:build linux
+
+package agent
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// restartDebounce lets a burst of syncs settle before restarting.
+	restartDebounce = 500 * time.Millisecond
+	// stopTimeout is how long the server gets to exit after each signal.
+	stopTimeout = 5 * time.Second
+)
+
+// Serve runs the API server and restarts it whenever the CLI rewrites the
+// trigger file, reinstalling the requirements first if they changed. It
+// stops the server and returns on SIGINT or SIGTERM.
+func Serve(cfg *Config, events *Emitter) error {
+	port, printedPort := cfg.APIPort, cfg.APIPort
+	if os.Getenv("BASE_RELEASE_VERSION") != "" {
+		port, printedPort = 7271, 7270
+	}
+
+	// Watch the directory rather than the file, which the CLI replaces.
+	trigger := filepath.Clean(cfg.TriggerFile)
+	if err := os.MkdirAll(filepath.Dir(trigger), 0755); err != nil {
+		return err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating file watcher: %w", err)
+	}
+	defer watcher.Close()
+	if err := watcher.Add(filepath.Dir(trigger)); err != nil {
+		return fmt.Errorf("watching %s: %w", trigger, err)
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	requirements := filepath.Join(cfg.ProjectDir, cfg.RequirementsPath)
+	requirementsSum := fileSum(requirements)
+	if venvChanged(cfg) {
+		go archiveInBackground(cfg, events)
+	}
+
+	server := &apiServer{cfg: cfg, port: port, events: events}
+	if err := server.start(); err != nil {
+		return err
+	}
+	events.Status("Connect to the API server at:")
+	events.Status(">  https://%s-%d.proxy.runpod.net", os.Getenv("RUNPOD_POD_ID"), printedPort)
+
+	var restart <-chan time.Time
+	for {
+		select {
+		case <-signals:
+			events.Status("Stopping API server...")
+			server.stop()
+			return nil
+
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if filepath.Clean(event.Name) == trigger && event.Has(fsnotify.Write|fsnotify.Create) {
+				restart = time.After(restartDebounce)
+			}
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			events.Error(fmt.Errorf("watching %s: %w", trigger, err))
+
+		case <-restart:
+			restart = nil
+			changed, _ := os.ReadFile(trigger)
+			events.Status("Found changes in: %s", strings.Join(strings.Fields(string(changed)), " "))
+			server.stop()
+
+			if sum := fileSum(requirements); sum != requirementsSum {
+				requirementsSum = sum
+				events.Status("Installing new requirements...")
+				venvMu.Lock()
+				err := installRequirements(cfg, events)
+				venvMu.Unlock()
+				if err != nil {
+					events.Error(err)
+				} else {
+					go archiveInBackground(cfg, events)
+				}
+			}
+			if err := server.start(); err != nil {
+				events.Error(err)
+			}
+
+		case err := <-server.exited:
+			server.cmd, server.exited = nil, nil
+			events.Error(fmt.Errorf("API server exited (%v); waiting for changes to restart it", err))
+		}
+	}
+}
+
+func archiveInBackground(cfg *Config, events *Emitter) {
+	if err := archiveVenv(cfg, events); err != nil {
+		events.Error(err)
+	}
+}
+
+// apiServer is the project's handler serving its API. It runs in a process
+// group of its own, so that stopping it also stops anything it started, and
+// dies with the agent.
+type apiServer struct {
+	cfg    *Config
+	port   int
+	events *Emitter
+
+	cmd    *exec.Cmd
+	exited chan error // receives the exit status if the server stops by itself
+}
+
+func (s *apiServer) start() error {
+	cmd := exec.Command(filepath.Join(s.cfg.VenvPath, "bin", "python"), s.cfg.HandlerPath,
+		"--rp_serve_api", "--rp_api_host=0.0.0.0", "--rp_api_port="+strconv.Itoa(s.port), "--rp_api_concurrency=1")
+	cmd.Dir = s.cfg.ProjectDir
+	cmd.Env = venvEnv(s.cfg)
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true, Pdeathsig: syscall.SIGKILL}
+	stdout, stderr := s.events.Output("stdout"), s.events.Output("stderr")
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	if err := cmd.Start(); err != nil {
+		stdout.Close()
+		stderr.Close()
+		return fmt.Errorf("starting API server: %w", err)
+	}
+
+	exited := make(chan error, 1)
+	go func() {
+		err := cmd.Wait()
+		stdout.Close()
+		stderr.Close()
+		exited <- err
+	}()
+	s.cmd, s.exited = cmd, exited
+	s.events.serverStatus(cmd.Process.Pid, "Started API server with PID: %d", cmd.Process.Pid)
+	return nil
+}
+
+// stop terminates the server, first gracefully and then with SIGKILL.
+func (s *apiServer) stop() {
+	if s.cmd == nil {
+		return
+	}
+	pid := s.cmd.Process.Pid
+	defer func() { s.cmd, s.exited = nil, nil }()
+
+	for _, sig := range []syscall.Signal{syscall.SIGTERM, syscall.SIGKILL} {
+		syscall.Kill(-pid, sig)
+		select {
+		case <-s.exited:
+			s.events.serverStatus(pid, "Stopped API server with PID: %d", pid)
+			return
+		case <-time.After(stopTimeout):
+		}
+	}
+	s.events.Error(fmt.Errorf("API server with PID %d did not exit after SIGKILL", pid))
+}
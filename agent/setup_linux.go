@@ -0,0 +1,236 @@
+//go:build linux
+
+package agent
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// venvMu keeps the agent from archiving the virtual environment twice at
+// once, or while installing into it.
+var venvMu sync.Mutex
+
+// Setup installs missing system packages, creates or restores the virtual
+// environment and installs the project's requirements into it.
+func Setup(cfg *Config, events *Emitter) error {
+	if err := ensurePackages(cfg, events); err != nil {
+		return err
+	}
+	if err := ensureVenv(cfg, events); err != nil {
+		return err
+	}
+	if err := installRequirements(cfg, events); err != nil {
+		return err
+	}
+	events.Status("Environment ready.")
+	return nil
+}
+
+// ensurePackages installs zstd, for the venv archive, and cfg.Packages with
+// apt-get when their command is missing, and uv when it is the package manager.
+func ensurePackages(cfg *Config, events *Emitter) error {
+	var missing []string
+	for _, pkg := range append([]string{"zstd"}, cfg.Packages...) {
+		if _, err := exec.LookPath(pkg); err != nil {
+			missing = append(missing, pkg)
+		}
+	}
+	if len(missing) > 0 {
+		events.Status("Installing %s ...", strings.Join(missing, ", "))
+		if err := run(events, "", nil, "apt-get", "update", "-qq"); err != nil {
+			return err
+		}
+		if err := run(events, "", nil, "apt-get", append([]string{"install", "-y", "-qq"}, missing...)...); err != nil {
+			return err
+		}
+	}
+
+	if cfg.PackageManager == "uv" {
+		if _, err := exec.LookPath("uv"); err != nil {
+			events.Status("Installing uv ...")
+			if err := run(events, "", nil, "pip", "install", "uv"); err != nil {
+				return err
+			}
+		}
+	}
+
+	if _, err := exec.LookPath("runpodctl"); err != nil {
+		events.Status("Installing runpodctl ...")
+		if err := run(events, "", nil, "sh", "-c", "wget -qO- cli.runpod.net | bash"); err != nil {
+			// Nothing in the dev loop needs it.
+			events.Error(fmt.Errorf("installing runpodctl: %w", err))
+		}
+	}
+	return nil
+}
+
+// ensureVenv restores the virtual environment from its archive on the
+// network volume, or creates it if there is none.
+func ensureVenv(cfg *Config, events *Emitter) error {
+	if _, err := os.Stat(filepath.Join(cfg.VenvPath, "bin", "activate")); err == nil {
+		return nil
+	}
+	if cfg.ArchivedVenvPath != "" {
+		if _, err := os.Stat(cfg.ArchivedVenvPath); err == nil {
+			events.Status("Extracting existing venv from archive: %s", cfg.ArchivedVenvPath)
+			if err := os.MkdirAll(cfg.VenvPath, 0755); err != nil {
+				return err
+			}
+			return run(events, "", nil, "tar", "--use-compress-program=zstd -d --threads=0", "-xf", cfg.ArchivedVenvPath, "-C", cfg.VenvPath)
+		}
+	}
+
+	events.Status("Creating new venv with %s...", cfg.PackageManager)
+	python := "python" + cfg.PythonVersion
+	switch cfg.PackageManager {
+	case "pip":
+		return run(events, "", nil, python, "-m", "venv", "--upgrade-deps", cfg.VenvPath)
+	case "uv":
+		return run(events, "", nil, "uv", "venv", "--python="+python, cfg.VenvPath)
+	}
+	return fmt.Errorf("unsupported package manager: %s", cfg.PackageManager)
+}
+
+func installRequirements(cfg *Config, events *Emitter) error {
+	events.Status("Installing Python dependencies...")
+	env := venvEnv(cfg)
+	switch cfg.PackageManager {
+	case "pip":
+		python := filepath.Join(cfg.VenvPath, "bin", "python")
+		return run(events, cfg.ProjectDir, env, python, "-m", "pip", "install", "-v", "--requirement", cfg.RequirementsPath, "--report", "/installreport.json", "hf_transfer")
+	case "uv":
+		return run(events, cfg.ProjectDir, env, "uv", "pip", "install", "--requirement", cfg.RequirementsPath, "hf_transfer")
+	}
+	return fmt.Errorf("unsupported package manager: %s", cfg.PackageManager)
+}
+
+// Archive stores the virtual environment on the network volume right away,
+// for when the Pod is about to stop.
+func Archive(cfg *Config, events *Emitter) error {
+	return archiveVenv(cfg, events)
+}
+
+// archiveVenv stores the virtual environment on the network volume, so that
+// the next Pod for the project can restore it instead of reinstalling.
+func archiveVenv(cfg *Config, events *Emitter) error {
+	if cfg.ArchivedVenvPath == "" {
+		return nil
+	}
+	venvMu.Lock()
+	defer venvMu.Unlock()
+
+	// Another agent, such as the one archiving as the CLI exits, may be
+	// writing the same archive. Volumes without locks are left to chance.
+	lock, err := os.OpenFile(cfg.ArchivedVenvPath+".lock", os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+	if err := syscall.Flock(int(lock.Fd()), syscall.LOCK_EX); err != nil && !errors.Is(err, syscall.ENOTSUP) && !errors.Is(err, syscall.ENOSYS) {
+		return fmt.Errorf("locking %s: %w", lock.Name(), err)
+	}
+
+	out, err := os.CreateTemp(filepath.Dir(cfg.ArchivedVenvPath), filepath.Base(cfg.ArchivedVenvPath)+".*.part")
+	if err != nil {
+		return err
+	}
+	partial := out.Name()
+	defer os.Remove(partial)
+
+	tar := exec.Command("tar", "-c", "-C", cfg.VenvPath, ".")
+	zstd := exec.Command("zstd", "-T0", "-q")
+	pipe, err := tar.StdoutPipe()
+	if err != nil {
+		out.Close()
+		return err
+	}
+	zstd.Stdin = pipe
+	zstd.Stdout = out
+	if err := zstd.Start(); err != nil {
+		out.Close()
+		return fmt.Errorf("zstd: %w", err)
+	}
+	tarErr := tar.Run()
+	zstdErr := zstd.Wait()
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if err := errors.Join(tarErr, zstdErr); err != nil {
+		return fmt.Errorf("archiving venv: %w", err)
+	}
+	if err := os.Chmod(partial, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(partial, cfg.ArchivedVenvPath); err != nil {
+		return err
+	}
+	events.Status("Synced venv to network volume")
+	return nil
+}
+
+// venvChanged reports whether packages may have been installed into or
+// removed from the virtual environment since it was last archived. Restoring
+// the archive keeps the directories' times from before it was made.
+func venvChanged(cfg *Config) bool {
+	archive, err := os.Stat(cfg.ArchivedVenvPath)
+	if err != nil {
+		return true
+	}
+	dirs, _ := filepath.Glob(filepath.Join(cfg.VenvPath, "lib", "python*", "site-packages"))
+	dirs = append(dirs, cfg.VenvPath, filepath.Join(cfg.VenvPath, "bin"))
+	for _, dir := range dirs {
+		if info, err := os.Stat(dir); err == nil && info.ModTime().After(archive.ModTime()) {
+			return true
+		}
+	}
+	return false
+}
+
+// venvEnv is the environment of a process run inside the virtual environment.
+func venvEnv(cfg *Config) []string {
+	return append(os.Environ(),
+		"VIRTUAL_ENV="+cfg.VenvPath,
+		"PATH="+filepath.Join(cfg.VenvPath, "bin")+string(os.PathListSeparator)+os.Getenv("PATH"),
+	)
+}
+
+// run runs name in dir, emitting its output as log events. A nil env keeps
+// the agent's own environment.
+func run(events *Emitter, dir string, env []string, name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Dir = dir
+	cmd.Env = env
+	stdout, stderr := events.Output("stdout"), events.Output("stderr")
+	cmd.Stdout, cmd.Stderr = stdout, stderr
+	err := cmd.Run()
+	stdout.Close()
+	stderr.Close()
+	if err != nil {
+		return fmt.Errorf("%s: %w", name, err)
+	}
+	return nil
+}
+
+// fileSum returns a hash of file's content, or "" if it cannot be read.
+func fileSum(file string) string {
+	f, err := os.Open(file)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	hash := sha256.New()
+	if _, err := io.Copy(hash, f); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,79 @@
+//go:build linux
+
+package agent
+
+import (
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestVenvChanged(t *testing.T) {
+	dir := t.TempDir()
+	cfg := &Config{VenvPath: filepath.Join(dir, "venv"), ArchivedVenvPath: filepath.Join(dir, "venv.tar.zst")}
+	sitePackages := filepath.Join(cfg.VenvPath, "lib", "python3.10", "site-packages")
+	os.MkdirAll(sitePackages, 0755)
+	os.MkdirAll(filepath.Join(cfg.VenvPath, "bin"), 0755)
+
+	if !venvChanged(cfg) {
+		t.Error("venvChanged = false without an archive")
+	}
+
+	archived := time.Now()
+	os.WriteFile(cfg.ArchivedVenvPath, []byte("archive"), 0644)
+	os.Chtimes(cfg.ArchivedVenvPath, archived, archived)
+	for _, d := range []string{sitePackages, cfg.VenvPath, filepath.Join(cfg.VenvPath, "bin")} {
+		os.Chtimes(d, archived.Add(-time.Hour), archived.Add(-time.Hour))
+	}
+	if venvChanged(cfg) {
+		t.Error("venvChanged = true for a venv as old as its archive")
+	}
+
+	// Installing a package adds it to site-packages
+	os.Chtimes(sitePackages, archived.Add(time.Minute), archived.Add(time.Minute))
+	if !venvChanged(cfg) {
+		t.Error("venvChanged = false after site-packages changed")
+	}
+}
+
+func TestArchiveVenvConcurrent(t *testing.T) {
+	for _, command := range []string{"tar", "zstd"} {
+		if _, err := exec.LookPath(command); err != nil {
+			t.Skipf("%s is not installed", command)
+		}
+	}
+	dir := t.TempDir()
+	cfg := &Config{VenvPath: filepath.Join(dir, "venv"), ArchivedVenvPath: filepath.Join(dir, "volume", "venv.tar.zst")}
+	os.MkdirAll(filepath.Join(cfg.VenvPath, "bin"), 0755)
+	os.MkdirAll(filepath.Dir(cfg.ArchivedVenvPath), 0755)
+	os.WriteFile(filepath.Join(cfg.VenvPath, "bin", "python"), make([]byte, 1<<20), 0755)
+
+	var wg sync.WaitGroup
+	errs := make([]error, 4)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = archiveVenv(cfg, NewEmitter(io.Discard))
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	out, err := exec.Command("sh", "-c", "zstd -d -q -c \"$1\" | tar -t", "sh", cfg.ArchivedVenvPath).CombinedOutput()
+	if err != nil {
+		t.Fatalf("archive is corrupt: %v\n%s", err, out)
+	}
+	leftovers, _ := filepath.Glob(cfg.ArchivedVenvPath + ".*.part")
+	if len(leftovers) != 0 {
+		t.Errorf("partial archives left behind: %v", leftovers)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"strings"
 )
 
 type CreateTemplateInput struct {
@@ -36,9 +37,43 @@ type CreateEndpointInput struct {
 
 // there are many more fields in the result of the query but I just care about these for CLI port
 type Endpoint struct {
-	Name string `json:"name"`
-	Id   string
+	Name            string `json:"name"`
+	Id              string
+	GpuIds          string
+	IdleTimeout     int
+	Locations       string
+	NetworkVolumeId string
+	ScalerType      string
+	ScalerValue     int
+	TemplateId      string
+	WorkersMax      int
+	WorkersMin      int
+	WorkersStandby  int
+	Env             []*PodEnv
+}
+
+// EnvVar returns the value of the environment variable key of the endpoint,
+// or "".
+func (e *Endpoint) EnvVar(key string) string {
+	for _, env := range e.Env {
+		if env.Key == key {
+			return env.Value
+		}
+	}
+	return ""
 }
+
+// Labels returns the labels stored in the endpoint environment.
+func (e *Endpoint) Labels() map[string]string {
+	labels := map[string]string{}
+	for _, env := range e.Env {
+		if strings.HasPrefix(env.Key, LabelEnvPrefix) {
+			labels[strings.TrimPrefix(env.Key, LabelEnvPrefix)] = env.Value
+		}
+	}
+	return labels
+}
+
 type EndpointOut struct {
 	Data   *EndpointData   `json:"data"`
 	Errors []*GraphQLError `json:"errors"`
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"io"
+	"sort"
 	"strings"
 )
 
@@ -42,14 +43,58 @@ type Pod struct {
 	Name              string
 	PodType           string
 	Ports             string
+	UptimeSeconds     int
 	VcpuCount         int
 	VolumeInGb        int
 	VolumeMountPath   string
 	Machine           *Machine
 	Runtime           *Runtime
 }
+
+// LabelEnvPrefix is the reserved environment variable prefix used to store
+// labels on a pod, e.g. the label team=ml is stored as PODFLOW_LABEL_team=ml.
+const LabelEnvPrefix = "PODFLOW_LABEL_"
+
+// Labels returns the labels stored in the pod environment.
+func (p *Pod) Labels() map[string]string {
+	labels := map[string]string{}
+	for _, e := range p.Env {
+		k, v, ok := strings.Cut(e, "=")
+		if ok && strings.HasPrefix(k, LabelEnvPrefix) {
+			labels[strings.TrimPrefix(k, LabelEnvPrefix)] = v
+		}
+	}
+	return labels
+}
+
+// EnvVar returns the value of the environment variable key of the pod, or "".
+func (p *Pod) EnvVar(key string) string {
+	for _, e := range p.Env {
+		if k, v, ok := strings.Cut(e, "="); ok && k == key {
+			return v
+		}
+	}
+	return ""
+}
+
+// LabelEnv converts labels into the pod environment variables that store them,
+// sorted by key so that the same labels always make the same environment.
+func LabelEnv(labels map[string]string) []*PodEnv {
+	keys := make([]string, 0, len(labels))
+	for k := range labels {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	env := []*PodEnv{}
+	for _, k := range keys {
+		env = append(env, &PodEnv{Key: LabelEnvPrefix + k, Value: labels[k]})
+	}
+	return env
+}
+
 type Machine struct {
 	GpuDisplayName string
+	PodHostId      string
 }
 type Runtime struct {
 	Ports []*PodRuntimePorts
@@ -90,6 +135,7 @@ func GetPods() (pods []*Pod, err error) {
 				volumeMountPath
 				machine {
 				  gpuDisplayName
+				  podHostId
 				}
 				runtime {
 				  ports {
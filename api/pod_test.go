@@ -0,0 +1,55 @@
+package api
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLabelEnv(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   []*PodEnv
+	}{
+		{name: "none", labels: nil, want: []*PodEnv{}},
+		{
+			name:   "sorted by key",
+			labels: map[string]string{"team": "ml", "role": "dev", "env": "", "a_1": "x=y"},
+			want: []*PodEnv{
+				{Key: "PODFLOW_LABEL_a_1", Value: "x=y"},
+				{Key: "PODFLOW_LABEL_env", Value: ""},
+				{Key: "PODFLOW_LABEL_role", Value: "dev"},
+				{Key: "PODFLOW_LABEL_team", Value: "ml"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 10; i++ {
+				if got := LabelEnv(tt.labels); !reflect.DeepEqual(got, tt.want) {
+					t.Fatalf("LabelEnv(%v) = %v, want %v", tt.labels, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestPodLabels(t *testing.T) {
+	pod := &Pod{Env: []string{
+		"PODFLOW_LABEL_team=ml",
+		"PODFLOW_LABEL_query=a=b",
+		"PODFLOW_LABEL_empty=",
+		"RUNPOD_PROJECT_ID=abc",
+		"PODFLOW_LABEL_broken",
+	}}
+	want := map[string]string{"team": "ml", "query": "a=b", "empty": ""}
+	if got := pod.Labels(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Labels() = %v, want %v", got, want)
+	}
+	if got := pod.EnvVar("RUNPOD_PROJECT_ID"); got != "abc" {
+		t.Errorf("EnvVar(RUNPOD_PROJECT_ID) = %q, want abc", got)
+	}
+	if got := pod.EnvVar("MISSING"); got != "" {
+		t.Errorf("EnvVar(MISSING) = %q, want empty", got)
+	}
+}
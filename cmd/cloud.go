@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"cli/cmd/cloud"
+
+	"github.com/spf13/cobra"
+)
+
+var cloudCmd = &cobra.Command{
+	Use:     "cloud [command]",
+	Short:   "Browse available cloud GPUs",
+	Long:    "List the GPU types available on runpod.io and their prices",
+	GroupID: "resource",
+}
+
+func init() {
+	cloudCmd.AddCommand(cloud.GetCloudCmd)
+}
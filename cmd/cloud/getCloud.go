@@ -7,7 +7,6 @@ import (
 	"os"
 	"strconv"
 
-	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
 
@@ -16,14 +15,37 @@ var disk int
 var memory int
 var vcpu int
 var secure bool
+var output format.Options
+
+// gpuItem is the machine-readable form of a cloud GPU listing. Prices are
+// nil when the GPU type is reserved and has no public price.
+type gpuItem struct {
+	GpuType       string   `json:"gpuType"`
+	GpuCount      int      `json:"gpuCount"`
+	MemoryInGb    float64  `json:"memoryInGb"`
+	VcpuCount     float64  `json:"vcpuCount"`
+	SpotPrice     *float64 `json:"spotPrice"`
+	OnDemandPrice *float64 `json:"onDemandPrice"`
+}
+
+var gpuColumns = []format.Column{
+	{Header: "GPU Type", Field: "gpuType"},
+	{Header: "Mem GB", Field: "memoryInGb"},
+	{Header: "vCPU", Field: "vcpuCount"},
+	{Header: "Spot $/HR", Field: "spotPrice"},
+	{Header: "OnDemand $/HR", Field: "onDemandPrice"},
+}
 
 var GetCloudCmd = &cobra.Command{
-	Use:   "cloud [gpuCount]",
-	Args:  cobra.MaximumNArgs(1),
-	Short: "get all cloud gpus",
-	Long:  "get all cloud gpus available on runpod.io",
+	Use:     "ls [gpuCount]",
+	Aliases: []string{"list"},
+	Args:    cobra.MaximumNArgs(1),
+	Short:   "get all cloud gpus",
+	Long:    "get all cloud gpus available on runpod.io",
 	Run: func(cmd *cobra.Command, args []string) {
-		var err error
+		printer, err := output.Printer()
+		cobra.CheckErr(err)
+
 		gpuCount := 1
 		if len(args) > 0 {
 			gpuCount, err = strconv.Atoi(args[0])
@@ -46,7 +68,8 @@ var GetCloudCmd = &cobra.Command{
 		gpuTypes, err := api.GetCloud(input)
 		cobra.CheckErr(err)
 
-		data := [][]string{}
+		list := &format.List{Columns: gpuColumns}
+		items := []gpuItem{}
 		for _, gpu := range gpuTypes {
 			gpuType, ok := gpu.(map[string]interface{})
 			if !ok {
@@ -56,32 +79,34 @@ var GetCloudCmd = &cobra.Command{
 			if !ok || kv["minMemory"] == nil {
 				continue
 			}
+			item := gpuItem{GpuCount: gpuCount}
+			item.GpuType, _ = kv["gpuTypeId"].(string)
+			item.MemoryInGb, _ = kv["minMemory"].(float64)
+			item.VcpuCount, _ = kv["minVcpu"].(float64)
 			spotPrice, ok := kv["minimumBidPrice"].(float64)
 			spotPriceString := "Reserved"
 			if ok && spotPrice > 0 {
+				item.SpotPrice = &spotPrice
 				spotPriceString = fmt.Sprintf("%.3f", spotPrice)
 			}
 			onDemandPrice, ok := kv["uninterruptablePrice"].(float64)
 			onDemandPriceString := "Reserved"
-			if ok && spotPrice > 0 {
+			if ok && onDemandPrice > 0 {
+				item.OnDemandPrice = &onDemandPrice
 				onDemandPriceString = fmt.Sprintf("%.3f", onDemandPrice)
 			}
-			row := []string{
-				fmt.Sprintf("%dx %s", gpuCount, kv["gpuTypeId"].(string)),
-				fmt.Sprintf("%.f", kv["minMemory"]),
-				fmt.Sprintf("%.f", kv["minVcpu"]),
+			items = append(items, item)
+			list.Rows = append(list.Rows, []string{
+				fmt.Sprintf("%dx %s", gpuCount, item.GpuType),
+				fmt.Sprintf("%.f", item.MemoryInGb),
+				fmt.Sprintf("%.f", item.VcpuCount),
 				spotPriceString,
 				onDemandPriceString,
-			}
-			data = append(data, row)
+			})
 		}
+		list.Items = items
 
-		header := []string{"GPU Type", "Mem GB", "vCPU", "Spot $/HR", "OnDemand $/HR"}
-		tb := tablewriter.NewWriter(os.Stdout)
-		tb.SetHeader(header)
-		tb.AppendBulk(data)
-		format.TableDefaults(tb)
-		tb.Render()
+		cobra.CheckErr(printer.Print(os.Stdout, list))
 	},
 }
 
@@ -91,4 +116,5 @@ func init() {
 	GetCloudCmd.Flags().IntVar(&memory, "mem", 0, "minimum sys memory size in GB you need")
 	GetCloudCmd.Flags().IntVar(&vcpu, "vcpu", 0, "minimum vCPUs you need")
 	GetCloudCmd.Flags().BoolVarP(&secure, "secure", "s", false, "show listings from secure cloud only")
+	output.AddFlags(GetCloudCmd)
 }
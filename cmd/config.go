@@ -21,4 +21,5 @@ func init() {
 	configCmd.AddCommand(config.AddKeyCmd)
 	configCmd.AddCommand(config.UrlCmd)
 	configCmd.AddCommand(config.GenKeyCmd)
+	configCmd.AddCommand(config.IdentityCmd)
 }
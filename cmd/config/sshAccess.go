@@ -34,3 +34,18 @@ var GenKeyCmd = &cobra.Command{
 		fmt.Println("SSH key added successfully.")
 	},
 }
+
+var IdentityCmd = &cobra.Command{
+	Use:   "ssh-identity [path to private key]",
+	Short: "Set the SSH private key used to connect to pods",
+	Long:  "Set the SSH private key used to connect to pods. It is tried after any identity_file in runpod.toml and before the key created by 'config ssh-key'. Keys in a running ssh-agent are always tried first.",
+	Args:  cobra.ExactArgs(1),
+	Run: func(c *cobra.Command, args []string) {
+		viper.Set("sshIdentityFile", args[0])
+		if err := viper.WriteConfig(); err != nil {
+			fmt.Printf("Error saving config: %v\n", err)
+			return
+		}
+		fmt.Println("SSH identity saved.")
+	},
+}
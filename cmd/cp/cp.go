@@ -0,0 +1,35 @@
+package cp
+
+import (
+	"cli/cmd/project"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	recursive bool
+	quiet     bool
+)
+
+var CopyCmd = &cobra.Command{
+	Use:     "cp <src> <dst>",
+	Args:    cobra.ExactArgs(2),
+	Short:   "Copy files to and from a pod",
+	GroupID: "resource",
+	Long: `Copy files between this machine and a pod over SFTP. No local rsync or ssh is needed.
+
+One side must be a pod path written as <podId|project>:<path>. Interrupted copies
+resume from where they stopped when run again.
+
+Example:
+  podflow cp model.bin abc123xyz:/workspace/
+  podflow cp -r my-project:/workspace/outputs ./outputs`,
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(Copy(args[0], args[1], project.CopyOptions{Recursive: recursive, Quiet: quiet}))
+	},
+}
+
+func init() {
+	CopyCmd.Flags().BoolVarP(&recursive, "recursive", "r", false, "copy directories recursively")
+	CopyCmd.Flags().BoolVarP(&quiet, "quiet", "q", false, "do not show progress bars")
+}
@@ -0,0 +1,54 @@
+package cp
+
+import (
+	"cli/cmd/project"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// podPath is a copy endpoint; pod is empty for local paths.
+type podPath struct {
+	pod  string
+	path string
+}
+
+// parsePath splits "<pod>:<path>". Windows drive letters such as C:\ are
+// treated as local paths.
+func parsePath(arg string) podPath {
+	pod, p, found := strings.Cut(arg, ":")
+	if !found || len(pod) == 1 || strings.ContainsAny(pod, `/\`) {
+		return podPath{path: arg}
+	}
+	if p == "" {
+		p = "."
+	}
+	return podPath{pod: pod, path: p}
+}
+
+// Copy transfers src to dst, exactly one of which must be on a pod.
+func Copy(src string, dst string, opts project.CopyOptions) error {
+	from, to := parsePath(src), parsePath(dst)
+	if (from.pod == "") == (to.pod == "") {
+		return errors.New("exactly one of <src> and <dst> must be a pod path (<podId|project>:<path>)")
+	}
+
+	target := from.pod
+	if target == "" {
+		target = to.pod
+	}
+	podId, err := project.ResolvePod(target)
+	if err != nil {
+		return err
+	}
+	sshConn, err := project.PodSSHConnection(podId)
+	if err != nil {
+		return fmt.Errorf("getting SSH connection: %w", err)
+	}
+	defer sshConn.Close()
+
+	if to.pod != "" {
+		return sshConn.Upload(from.path, to.path, opts)
+	}
+	return sshConn.Download(from.path, to.path, opts)
+}
@@ -0,0 +1,18 @@
+package cmd
+
+import (
+	"cli/cmd/endpoint"
+
+	"github.com/spf13/cobra"
+)
+
+var endpointCmd = &cobra.Command{
+	Use:     "endpoint [command]",
+	Short:   "Manage serverless endpoints",
+	Long:    "List and watch the serverless endpoints in your runpod.io account",
+	GroupID: "resource",
+}
+
+func init() {
+	endpointCmd.AddCommand(endpoint.ListEndpointsCmd)
+}
@@ -0,0 +1,140 @@
+package endpoint
+
+import (
+	"cli/api"
+	"cli/cmd/watch"
+	"cli/format"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var output format.Options
+var watchEndpoints bool
+var watchInterval time.Duration
+
+// endpointItem is the machine-readable form of a serverless endpoint.
+type endpointItem struct {
+	Id              string `json:"id"`
+	Name            string `json:"name"`
+	GpuIds          string `json:"gpuIds"`
+	WorkersMin      int    `json:"workersMin"`
+	WorkersMax      int    `json:"workersMax"`
+	IdleTimeout     int    `json:"idleTimeout"`
+	ScalerType      string `json:"scalerType"`
+	ScalerValue     int    `json:"scalerValue"`
+	TemplateId      string `json:"templateId"`
+	NetworkVolumeId string `json:"networkVolumeId"`
+	Locations       string `json:"locations"`
+}
+
+var endpointColumns = []format.Column{
+	{Header: "ID", Field: "id"},
+	{Header: "Name", Field: "name"},
+	{Header: "GPUs", Field: "gpuIds"},
+	{Header: "Workers", Fields: []string{"workersMin", "workersMax"}},
+	{Header: "Idle Timeout", Field: "idleTimeout", Wide: true},
+	{Header: "Scaler", Fields: []string{"scalerType", "scalerValue"}, Wide: true},
+	{Header: "Template", Field: "templateId", Wide: true},
+	{Header: "Network Volume", Field: "networkVolumeId", Wide: true},
+}
+
+var ListEndpointsCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Args:    cobra.ExactArgs(0),
+	Short:   "list serverless endpoints",
+	Long:    "list all serverless endpoints on runpod.io",
+	Run: func(cmd *cobra.Command, args []string) {
+		printer, err := output.Printer()
+		cobra.CheckErr(err)
+		if watchEndpoints && output.IsStructured() && output.Output != "json" {
+			cobra.CheckErr(fmt.Errorf("--watch supports -o table, wide or json"))
+		}
+
+		opts := watch.Options{Interval: watchInterval, JSON: output.IsStructured()}
+		if !watchEndpoints || !output.IsStructured() {
+			endpoints, err := api.GetEndpoints()
+			cobra.CheckErr(err)
+			cobra.CheckErr(printer.Print(os.Stdout, endpointList(endpoints)))
+			opts.Baseline = endpointResources(endpoints)
+		}
+
+		if watchEndpoints {
+			cobra.CheckErr(watch.Run(opts, watchResources, os.Stdout))
+		}
+	},
+}
+
+func newEndpointItem(e *api.Endpoint) endpointItem {
+	return endpointItem{
+		Id:              e.Id,
+		Name:            e.Name,
+		GpuIds:          e.GpuIds,
+		WorkersMin:      e.WorkersMin,
+		WorkersMax:      e.WorkersMax,
+		IdleTimeout:     e.IdleTimeout,
+		ScalerType:      e.ScalerType,
+		ScalerValue:     e.ScalerValue,
+		TemplateId:      e.TemplateId,
+		NetworkVolumeId: e.NetworkVolumeId,
+		Locations:       e.Locations,
+	}
+}
+
+func endpointList(endpoints []*api.Endpoint) *format.List {
+	list := &format.List{Columns: endpointColumns}
+	items := []endpointItem{}
+	for _, e := range endpoints {
+		item := newEndpointItem(e)
+		items = append(items, item)
+		list.Rows = append(list.Rows, []string{
+			item.Id,
+			item.Name,
+			item.GpuIds,
+			fmt.Sprintf("%d-%d", item.WorkersMin, item.WorkersMax),
+			fmt.Sprintf("%ds", item.IdleTimeout),
+			fmt.Sprintf("%s %d", item.ScalerType, item.ScalerValue),
+			item.TemplateId,
+			item.NetworkVolumeId,
+		})
+	}
+	list.Items = items
+	return list
+}
+
+// watchResources fetches the endpoints for --watch.
+func watchResources() ([]watch.Resource, error) {
+	endpoints, err := api.GetEndpoints()
+	if err != nil {
+		return nil, err
+	}
+	return endpointResources(endpoints), nil
+}
+
+// endpointResources reduces endpoints to the fields whose changes are reported by --watch.
+func endpointResources(endpoints []*api.Endpoint) []watch.Resource {
+	resources := make([]watch.Resource, 0, len(endpoints))
+	for _, e := range endpoints {
+		resources = append(resources, watch.Resource{
+			Kind: "endpoint",
+			Id:   e.Id,
+			Name: e.Name,
+			Fields: map[string]string{
+				"templateId":  e.TemplateId,
+				"gpuIds":      e.GpuIds,
+				"workers":     fmt.Sprintf("%d-%d", e.WorkersMin, e.WorkersMax),
+				"idleTimeout": fmt.Sprintf("%ds", e.IdleTimeout),
+			},
+		})
+	}
+	return resources
+}
+
+func init() {
+	ListEndpointsCmd.Flags().BoolVarP(&watchEndpoints, "watch", "w", false, "keep polling and print template, worker and membership changes")
+	ListEndpointsCmd.Flags().DurationVar(&watchInterval, "interval", 5*time.Second, "polling interval for --watch; after a failed poll it doubles, up to 2m or the interval if longer, until one succeeds")
+	output.AddFlags(ListEndpointsCmd)
+}
@@ -8,9 +8,10 @@ import (
 
 // execCmd represents the base command for executing commands in a pod
 var execCmd = &cobra.Command{
-	Use:   "exec",
-	Short: "Execute commands in a pod",
-	Long:  `Execute a local file remotely in a pod.`,
+	Use:     "exec",
+	Short:   "Execute commands in a pod",
+	Long:    `Execute a local file remotely in a pod.`,
+	GroupID: "resource",
 }
 
 func init() {
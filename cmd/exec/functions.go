@@ -3,6 +3,7 @@ package exec
 import (
 	"cli/cmd/project"
 	"fmt"
+	"path/filepath"
 )
 
 func PythonOverSSH(podID string, file string) error {
@@ -11,13 +12,16 @@ func PythonOverSSH(podID string, file string) error {
 		return fmt.Errorf("getting SSH connection: %w", err)
 	}
 
-	// Copy the file to the pod using Rsync
-	if err := sshConn.Rsync(file, "/tmp/"+file, false); err != nil {
+	defer sshConn.Close()
+
+	// Copy the file to the pod
+	remoteFile := "/tmp/" + filepath.Base(file)
+	if err := sshConn.Upload(file, remoteFile, project.CopyOptions{Quiet: true}); err != nil {
 		return fmt.Errorf("copying file to pod: %w", err)
 	}
 
 	// Run the file on the pod
-	if err := sshConn.RunCommand("python3.11 /tmp/" + file); err != nil {
+	if err := sshConn.RunCommand("python3.11 " + remoteFile); err != nil {
 		return fmt.Errorf("running Python command: %w", err)
 	}
 
@@ -7,13 +7,15 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// getCmd keeps the old verb-first paths working; use the resource groups instead.
 var getCmd = &cobra.Command{
-	Use:   "get [command]",
-	Short: "get resource",
-	Long:  "get resources for pods",
+	Use:    "get [command]",
+	Short:  "get resource",
+	Long:   "get resources for pods",
+	Hidden: true,
 }
 
 func init() {
-	getCmd.AddCommand(cloud.GetCloudCmd)
-	getCmd.AddCommand(pod.GetPodCmd)
+	getCmd.AddCommand(deprecatedAlias("cloud [gpuCount]", cloud.GetCloudCmd, "podflow cloud ls"))
+	getCmd.AddCommand(deprecatedAlias("pod [podId]", pod.GetPodCmd, "podflow pod ls"))
 }
@@ -0,0 +1,19 @@
+package cmd
+
+import (
+	"cli/cmd/project"
+
+	"github.com/spf13/cobra"
+)
+
+// ignoreCmd groups the commands for inspecting .runpodignore rules
+var ignoreCmd = &cobra.Command{
+	Use:     "ignore",
+	Short:   "Inspect which project files are synced",
+	Long:    `Inspect how the default patterns and .runpodignore files decide which project files are synced to the Pod.`,
+	GroupID: "project",
+}
+
+func init() {
+	ignoreCmd.AddCommand(project.IgnoreCheckCmd)
+}
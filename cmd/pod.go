@@ -0,0 +1,24 @@
+package cmd
+
+import (
+	"cli/cmd/pod"
+
+	"github.com/spf13/cobra"
+)
+
+var podCmd = &cobra.Command{
+	Use:     "pod [command]",
+	Short:   "Manage pods",
+	Long:    "List, create, start, stop, remove and connect to pods on runpod.io",
+	GroupID: "resource",
+}
+
+func init() {
+	podCmd.AddCommand(pod.GetPodCmd)
+	podCmd.AddCommand(pod.CreatePodCmd)
+	podCmd.AddCommand(pod.StartPodCmd)
+	podCmd.AddCommand(pod.StopPodCmd)
+	podCmd.AddCommand(pod.RemovePodCmd)
+	podCmd.AddCommand(pod.SSHPodCmd)
+	podCmd.AddCommand(pod.ExecPodCmd)
+}
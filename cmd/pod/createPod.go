@@ -17,6 +17,7 @@ var env []string
 var gpuCount int
 var gpuTypeId string
 var imageName string
+var labels []string
 var minMemoryInGb int
 var minVcpuCount int
 var name string
@@ -26,10 +27,10 @@ var volumeInGb int
 var volumeMountPath string
 
 var CreatePodCmd = &cobra.Command{
-	Use:   "pod",
+	Use:   "create",
 	Args:  cobra.ExactArgs(0),
-	Short: "start a pod",
-	Long:  "start a pod from runpod.io",
+	Short: "create a pod",
+	Long:  "create a pod on runpod.io",
 	Run: func(cmd *cobra.Command, args []string) {
 		input := &api.CreatePodInput{
 			ContainerDiskInGb: containerDiskInGb,
@@ -56,6 +57,9 @@ var CreatePodCmd = &cobra.Command{
 			}
 			input.Env[i] = &api.PodEnv{Key: e[0], Value: e[1]}
 		}
+		podLabels, err := ParseLabels(labels)
+		cobra.CheckErr(err)
+		input.Env = append(input.Env, api.LabelEnv(podLabels)...)
 		if secureCloud {
 			input.CloudType = "SECURE"
 		} else {
@@ -83,6 +87,7 @@ func init() {
 	CreatePodCmd.Flags().IntVar(&gpuCount, "gpuCount", 1, "number of GPUs for the pod")
 	CreatePodCmd.Flags().StringVar(&gpuTypeId, "gpuType", "", "gpu type id, e.g. 'NVIDIA GeForce RTX 3090'")
 	CreatePodCmd.Flags().StringVar(&imageName, "imageName", "", "container image name")
+	CreatePodCmd.Flags().StringSliceVarP(&labels, "label", "l", nil, "labels to attach to the pod, e.g. team=ml")
 	CreatePodCmd.Flags().IntVar(&minMemoryInGb, "mem", 20, "minimum system memory needed")
 	CreatePodCmd.Flags().IntVar(&minVcpuCount, "vcpu", 1, "minimum vCPUs needed")
 	CreatePodCmd.Flags().StringVar(&name, "name", "", "any pod name for easy reference")
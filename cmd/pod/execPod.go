@@ -0,0 +1,19 @@
+package pod
+
+import (
+	"cli/cmd/project"
+
+	"github.com/spf13/cobra"
+)
+
+var ExecPodCmd = &cobra.Command{
+	Use:   "exec [podId] -- [command]",
+	Args:  cobra.MinimumNArgs(2),
+	Short: "run a command on a pod",
+	Long:  "run a command on a running pod over SSH and stream its output; arguments are passed as they are, so use sh -c '...' for pipes or redirection",
+	Run: func(cmd *cobra.Command, args []string) {
+		sshConn, err := project.PodSSHConnection(args[0])
+		cobra.CheckErr(err)
+		cobra.CheckErr(sshConn.RunCommand(project.ShellCommand(args[1:])))
+	},
+}
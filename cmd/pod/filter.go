@@ -0,0 +1,163 @@
+package pod
+
+import (
+	"cli/api"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/gobwas/glob"
+)
+
+// PodFilter selects and orders pods for the listing commands.
+type PodFilter struct {
+	Id       string
+	Status   string
+	Name     string
+	Gpu      string
+	Image    string
+	Selector []string
+	SortBy   string
+}
+
+type labelRequirement struct {
+	key    string
+	value  string
+	op     string // "=", "!=" or "exists"
+	negate bool   // "!key": the label must be absent
+}
+
+var labelKeyPattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// ValidateLabelKey makes sure a label key can be stored in an environment variable name.
+func ValidateLabelKey(key string) error {
+	if !labelKeyPattern.MatchString(key) {
+		return fmt.Errorf("invalid label key %q: use letters, digits and underscores", key)
+	}
+	return nil
+}
+
+// ParseLabels parses key=value pairs as given to --label.
+func ParseLabels(pairs []string) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, pair := range pairs {
+		k, v, ok := strings.Cut(pair, "=")
+		if !ok {
+			return nil, fmt.Errorf("wrong label value: %s (expected key=value)", pair)
+		}
+		if err := ValidateLabelKey(k); err != nil {
+			return nil, err
+		}
+		labels[k] = v
+	}
+	return labels, nil
+}
+
+// parseSelector parses label selectors such as "team=ml,role!=prod,gpu,!tmp".
+func parseSelector(selectors []string) ([]labelRequirement, error) {
+	reqs := []labelRequirement{}
+	for _, selector := range selectors {
+		for _, term := range strings.Split(selector, ",") {
+			term = strings.TrimSpace(term)
+			if term == "" {
+				continue
+			}
+			var req labelRequirement
+			switch {
+			case strings.Contains(term, "!="):
+				k, v, _ := strings.Cut(term, "!=")
+				req = labelRequirement{key: k, value: v, op: "!="}
+			case strings.Contains(term, "="):
+				k, v, _ := strings.Cut(term, "=")
+				req = labelRequirement{key: k, value: strings.TrimPrefix(v, "="), op: "="}
+			case strings.HasPrefix(term, "!"):
+				req = labelRequirement{key: term[1:], op: "exists", negate: true}
+			default:
+				req = labelRequirement{key: term, op: "exists"}
+			}
+			if err := ValidateLabelKey(req.key); err != nil {
+				return nil, fmt.Errorf("bad selector %q: %w", term, err)
+			}
+			reqs = append(reqs, req)
+		}
+	}
+	return reqs, nil
+}
+
+func (r labelRequirement) matches(labels map[string]string) bool {
+	v, ok := labels[r.key]
+	switch r.op {
+	case "=":
+		return ok && v == r.value
+	case "!=":
+		return !ok || v != r.value
+	default:
+		return ok != r.negate
+	}
+}
+
+// Apply returns the pods that match every filter, sorted as requested.
+func (f *PodFilter) Apply(pods []*api.Pod) ([]*api.Pod, error) {
+	reqs, err := parseSelector(f.Selector)
+	if err != nil {
+		return nil, err
+	}
+	var nameGlob, imageGlob glob.Glob
+	if f.Name != "" {
+		if nameGlob, err = glob.Compile(f.Name); err != nil {
+			return nil, fmt.Errorf("bad --name pattern: %w", err)
+		}
+	}
+	if f.Image != "" {
+		if imageGlob, err = glob.Compile(f.Image); err != nil {
+			return nil, fmt.Errorf("bad --image pattern: %w", err)
+		}
+	}
+
+	matched := []*api.Pod{}
+	for _, p := range pods {
+		if f.Id != "" && p.Id != strings.ToLower(f.Id) {
+			continue
+		}
+		if f.Status != "" && !strings.EqualFold(p.DesiredStatus, f.Status) {
+			continue
+		}
+		if nameGlob != nil && !nameGlob.Match(p.Name) {
+			continue
+		}
+		if imageGlob != nil && !imageGlob.Match(p.ImageName) {
+			continue
+		}
+		if f.Gpu != "" && (p.Machine == nil || !strings.Contains(strings.ToLower(p.Machine.GpuDisplayName), strings.ToLower(f.Gpu))) {
+			continue
+		}
+		if len(reqs) > 0 {
+			labels := p.Labels()
+			ok := true
+			for _, req := range reqs {
+				if !req.matches(labels) {
+					ok = false
+					break
+				}
+			}
+			if !ok {
+				continue
+			}
+		}
+		matched = append(matched, p)
+	}
+
+	switch f.SortBy {
+	case "":
+	case "cost":
+		sort.SliceStable(matched, func(i, j int) bool { return matched[i].CostPerHr > matched[j].CostPerHr })
+	case "name":
+		sort.SliceStable(matched, func(i, j int) bool { return matched[i].Name < matched[j].Name })
+	case "uptime":
+		sort.SliceStable(matched, func(i, j int) bool { return matched[i].UptimeSeconds > matched[j].UptimeSeconds })
+	default:
+		return nil, fmt.Errorf("unknown --sort-by %q: use cost, name or uptime", f.SortBy)
+	}
+	return matched, nil
+}
@@ -0,0 +1,140 @@
+package pod
+
+import (
+	"cli/api"
+	"reflect"
+	"testing"
+)
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		name      string
+		selectors []string
+		want      []labelRequirement
+		wantErr   bool
+	}{
+		{name: "empty", selectors: nil, want: []labelRequirement{}},
+		{
+			name:      "all operators",
+			selectors: []string{"team=ml,role!=prod, gpu ,!tmp", "env==test"},
+			want: []labelRequirement{
+				{key: "team", value: "ml", op: "="},
+				{key: "role", value: "prod", op: "!="},
+				{key: "gpu", op: "exists"},
+				{key: "tmp", op: "exists", negate: true},
+				{key: "env", value: "test", op: "="},
+			},
+		},
+		{name: "empty value", selectors: []string{"team="}, want: []labelRequirement{{key: "team", op: "="}}},
+		{name: "skips empty terms", selectors: []string{",team,,"}, want: []labelRequirement{{key: "team", op: "exists"}}},
+		{name: "bad key", selectors: []string{"team-name=ml"}, wantErr: true},
+		{name: "missing key", selectors: []string{"=ml"}, wantErr: true},
+		{name: "bad negated key", selectors: []string{"!1team"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSelector(tt.selectors)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSelector(%q) error = %v, wantErr %v", tt.selectors, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseSelector(%q) = %+v, want %+v", tt.selectors, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLabelRequirementMatches(t *testing.T) {
+	labels := map[string]string{"team": "ml", "role": "dev", "empty": ""}
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{"team=ml", true},
+		{"team=web", false},
+		{"missing=ml", false},
+		{"empty=", true},
+		{"team!=web", true},
+		{"team!=ml", false},
+		{"missing!=ml", true},
+		{"role", true},
+		{"empty", true},
+		{"missing", false},
+		{"!missing", true},
+		{"!role", false},
+	}
+	for _, tt := range tests {
+		reqs, err := parseSelector([]string{tt.selector})
+		if err != nil {
+			t.Fatalf("parseSelector(%q): %v", tt.selector, err)
+		}
+		if got := reqs[0].matches(labels); got != tt.want {
+			t.Errorf("%q matches %v = %v, want %v", tt.selector, labels, got, tt.want)
+		}
+	}
+}
+
+func TestParseLabels(t *testing.T) {
+	got, err := ParseLabels([]string{"team=ml", "query=a=b", "empty="})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"team": "ml", "query": "a=b", "empty": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseLabels = %v, want %v", got, want)
+	}
+	for _, bad := range []string{"team", "team-name=ml", "=ml"} {
+		if _, err := ParseLabels([]string{bad}); err == nil {
+			t.Errorf("ParseLabels(%q): expected an error", bad)
+		}
+	}
+}
+
+func TestPodFilterApply(t *testing.T) {
+	pods := []*api.Pod{
+		{Id: "a1", Name: "team-train", ImageName: "runpod/pytorch:2.1", DesiredStatus: "RUNNING", CostPerHr: 0.69, UptimeSeconds: 60,
+			Machine: &api.Machine{GpuDisplayName: "RTX 4090"}, Env: []string{"PODFLOW_LABEL_team=ml", "PODFLOW_LABEL_role=dev"}},
+		{Id: "b2", Name: "web", ImageName: "nginx:latest", DesiredStatus: "EXITED", CostPerHr: 0.2, UptimeSeconds: 0,
+			Env: []string{"PODFLOW_LABEL_team=web"}},
+		{Id: "c3", Name: "team-eval", ImageName: "runpod/pytorch:2.2", DesiredStatus: "RUNNING", CostPerHr: 2.5, UptimeSeconds: 3600,
+			Machine: &api.Machine{GpuDisplayName: "A100 SXM"}, Env: []string{"PODFLOW_LABEL_team=ml"}},
+	}
+	tests := []struct {
+		name    string
+		filter  PodFilter
+		want    []string
+		wantErr bool
+	}{
+		{name: "no filter", filter: PodFilter{}, want: []string{"a1", "b2", "c3"}},
+		{name: "id is case insensitive", filter: PodFilter{Id: "B2"}, want: []string{"b2"}},
+		{name: "status", filter: PodFilter{Status: "running"}, want: []string{"a1", "c3"}},
+		{name: "name glob", filter: PodFilter{Name: "team-*"}, want: []string{"a1", "c3"}},
+		{name: "image glob", filter: PodFilter{Image: "runpod/*"}, want: []string{"a1", "c3"}},
+		{name: "gpu substring", filter: PodFilter{Gpu: "a100"}, want: []string{"c3"}},
+		{name: "selector", filter: PodFilter{Selector: []string{"team=ml", "!role"}}, want: []string{"c3"}},
+		{name: "sort by cost", filter: PodFilter{SortBy: "cost"}, want: []string{"c3", "a1", "b2"}},
+		{name: "sort by name", filter: PodFilter{SortBy: "name"}, want: []string{"c3", "a1", "b2"}},
+		{name: "sort by uptime", filter: PodFilter{SortBy: "uptime"}, want: []string{"c3", "a1", "b2"}},
+		{name: "unknown sort", filter: PodFilter{SortBy: "gpu"}, wantErr: true},
+		{name: "bad name glob", filter: PodFilter{Name: "[team"}, wantErr: true},
+		{name: "bad selector", filter: PodFilter{Selector: []string{"a-b"}}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.filter.Apply(pods)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Apply error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			ids := []string{}
+			for _, p := range got {
+				ids = append(ids, p.Id)
+			}
+			if !reflect.DeepEqual(ids, tt.want) {
+				t.Errorf("Apply = %v, want %v", ids, tt.want)
+			}
+		})
+	}
+}
@@ -2,59 +2,196 @@ package pod
 
 import (
 	"cli/api"
+	"cli/cmd/watch"
 	"cli/format"
 	"fmt"
 	"os"
+	"sort"
 	"strings"
+	"time"
 
-	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
 )
 
 var AllFields bool
+var output format.Options
+var filter PodFilter
+var watchPods bool
+var watchInterval time.Duration
+
+// podItem is the machine-readable form of a pod; its json tags are the
+// stable field names used by every structured output format.
+type podItem struct {
+	Id                string            `json:"id"`
+	Name              string            `json:"name"`
+	GpuCount          int               `json:"gpuCount"`
+	GpuType           string            `json:"gpuType"`
+	ImageName         string            `json:"imageName"`
+	Status            string            `json:"status"`
+	PodType           string            `json:"podType"`
+	VcpuCount         int               `json:"vcpuCount"`
+	MemoryInGb        int               `json:"memoryInGb"`
+	ContainerDiskInGb int               `json:"containerDiskInGb"`
+	VolumeInGb        int               `json:"volumeInGb"`
+	CostPerHr         float32           `json:"costPerHr"`
+	UptimeSeconds     int               `json:"uptimeSeconds"`
+	Labels            map[string]string `json:"labels"`
+}
+
+var podColumns = []format.Column{
+	{Header: "ID", Field: "id"},
+	{Header: "Name", Field: "name"},
+	{Header: "GPU", Fields: []string{"gpuCount", "gpuType"}},
+	{Header: "Image Name", Field: "imageName"},
+	{Header: "Status", Field: "status"},
+	{Header: "Pod Type", Field: "podType", Wide: true},
+	{Header: "vCPU", Field: "vcpuCount", Wide: true},
+	{Header: "Mem", Field: "memoryInGb", Wide: true},
+	{Header: "Container Disk", Field: "containerDiskInGb", Wide: true},
+	{Header: "Volume Disk", Field: "volumeInGb", Wide: true},
+	{Header: "$/hr", Field: "costPerHr", Wide: true},
+	{Header: "Uptime", Field: "uptimeSeconds", Wide: true},
+	{Header: "Labels", Field: "labels", Wide: true},
+}
 
 var GetPodCmd = &cobra.Command{
-	Use:   "pod [podId]",
-	Args:  cobra.MaximumNArgs(1),
-	Short: "get all pods",
-	Long:  "get all pods or specify pod id",
+	Use:     "ls [podId]",
+	Aliases: []string{"list"},
+	Args:    cobra.MaximumNArgs(1),
+	Short:   "get all pods",
+	Long:    "get all pods or specify pod id; filter with --status, --name, --gpu, --image and -l label selectors",
 	Run: func(cmd *cobra.Command, args []string) {
-		pods, err := api.GetPods()
+		if AllFields && output.Output == "table" {
+			output.Output = "wide"
+		}
+		printer, err := output.Printer()
 		cobra.CheckErr(err)
 
-		data := make([][]string, len(pods))
-		for i, p := range pods {
-			if len(args) == 1 && p.Id != strings.ToLower(args[0]) {
-				continue
-			}
-			row := []string{p.Id, p.Name, fmt.Sprintf("%d %s", p.GpuCount, p.Machine.GpuDisplayName), p.ImageName, p.DesiredStatus}
-			if AllFields {
-				row = append(
-					row,
-					p.PodType,
-					fmt.Sprintf("%d", p.VcpuCount),
-					fmt.Sprintf("%d", p.MemoryInGb),
-					fmt.Sprintf("%d", p.ContainerDiskInGb),
-					fmt.Sprintf("%d", p.VolumeInGb),
-					fmt.Sprintf("%.3f", p.CostPerHr),
-				)
-			}
-			data[i] = row
+		if len(args) == 1 {
+			filter.Id = args[0]
+		}
+		if watchPods && output.IsStructured() && output.Output != "json" {
+			cobra.CheckErr(fmt.Errorf("--watch supports -o table, wide or json"))
 		}
 
-		header := []string{"ID", "Name", "GPU", "Image Name", "Status"}
-		if AllFields {
-			header = append(header, "Pod Type", "vCPU", "Mem", "Container Disk", "Volume Disk", "$/hr")
+		opts := watch.Options{Interval: watchInterval, JSON: output.IsStructured()}
+		// In json watch mode the initial pods are reported as ADDED events instead
+		if !watchPods || !output.IsStructured() {
+			pods, err := fetchPods()
+			cobra.CheckErr(err)
+			cobra.CheckErr(printer.Print(os.Stdout, podList(pods)))
+			opts.Baseline = podResources(pods)
 		}
 
-		tb := tablewriter.NewWriter(os.Stdout)
-		tb.SetHeader(header)
-		tb.AppendBulk(data)
-		format.TableDefaults(tb)
-		tb.Render()
+		if watchPods {
+			cobra.CheckErr(watch.Run(opts, watchResources, os.Stdout))
+		}
 	},
 }
 
+func fetchPods() ([]*api.Pod, error) {
+	pods, err := api.GetPods()
+	if err != nil {
+		return nil, err
+	}
+	return filter.Apply(pods)
+}
+
+func podList(pods []*api.Pod) *format.List {
+	list := &format.List{Columns: podColumns}
+	items := []podItem{}
+	for _, p := range pods {
+		item := newPodItem(p)
+		items = append(items, item)
+		list.Rows = append(list.Rows, []string{
+			item.Id,
+			item.Name,
+			fmt.Sprintf("%d %s", item.GpuCount, item.GpuType),
+			item.ImageName,
+			item.Status,
+			item.PodType,
+			fmt.Sprintf("%d", item.VcpuCount),
+			fmt.Sprintf("%d", item.MemoryInGb),
+			fmt.Sprintf("%d", item.ContainerDiskInGb),
+			fmt.Sprintf("%d", item.VolumeInGb),
+			fmt.Sprintf("%.3f", item.CostPerHr),
+			format.Uptime(item.UptimeSeconds),
+			formatLabels(item.Labels),
+		})
+	}
+	list.Items = items
+	return list
+}
+
+// watchResources fetches the filtered pods for --watch.
+func watchResources() ([]watch.Resource, error) {
+	pods, err := fetchPods()
+	if err != nil {
+		return nil, err
+	}
+	return podResources(pods), nil
+}
+
+// podResources reduces pods to the fields whose changes are reported by --watch.
+func podResources(pods []*api.Pod) []watch.Resource {
+	resources := make([]watch.Resource, 0, len(pods))
+	for _, p := range pods {
+		item := newPodItem(p)
+		resources = append(resources, watch.Resource{
+			Kind: "pod",
+			Id:   item.Id,
+			Name: item.Name,
+			Fields: map[string]string{
+				"status":    item.Status,
+				"costPerHr": fmt.Sprintf("%.3f", item.CostPerHr),
+				"gpu":       fmt.Sprintf("%d %s", item.GpuCount, item.GpuType),
+				"imageName": item.ImageName,
+			},
+		})
+	}
+	return resources
+}
+
+func newPodItem(p *api.Pod) podItem {
+	item := podItem{
+		Id:                p.Id,
+		Name:              p.Name,
+		GpuCount:          p.GpuCount,
+		ImageName:         p.ImageName,
+		Status:            p.DesiredStatus,
+		PodType:           p.PodType,
+		VcpuCount:         p.VcpuCount,
+		MemoryInGb:        p.MemoryInGb,
+		ContainerDiskInGb: p.ContainerDiskInGb,
+		VolumeInGb:        p.VolumeInGb,
+		CostPerHr:         p.CostPerHr,
+		UptimeSeconds:     p.UptimeSeconds,
+		Labels:            p.Labels(),
+	}
+	if p.Machine != nil {
+		item.GpuType = p.Machine.GpuDisplayName
+	}
+	return item
+}
+
+func formatLabels(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for k, v := range labels {
+		pairs = append(pairs, k+"="+v)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
 func init() {
-	GetPodCmd.Flags().BoolVarP(&AllFields, "allfields", "a", false, "include all fields in output")
+	GetPodCmd.Flags().BoolVarP(&AllFields, "allfields", "a", false, "include all fields in output (same as -o wide)")
+	GetPodCmd.Flags().StringVar(&filter.Status, "status", "", "only show pods with this status, e.g. RUNNING or EXITED")
+	GetPodCmd.Flags().StringVar(&filter.Name, "name", "", "only show pods whose name matches this glob, e.g. 'team-*'")
+	GetPodCmd.Flags().StringVar(&filter.Gpu, "gpu", "", "only show pods whose GPU name contains this text, e.g. 4090")
+	GetPodCmd.Flags().StringVar(&filter.Image, "image", "", "only show pods whose image matches this glob, e.g. 'runpod/*'")
+	GetPodCmd.Flags().StringSliceVarP(&filter.Selector, "selector", "l", nil, "label selector, e.g. -l team=ml,role!=prod")
+	GetPodCmd.Flags().StringVar(&filter.SortBy, "sort-by", "", "sort pods by cost, name or uptime")
+	GetPodCmd.Flags().BoolVarP(&watchPods, "watch", "w", false, "keep polling and print status, cost and membership changes")
+	GetPodCmd.Flags().DurationVar(&watchInterval, "interval", 5*time.Second, "polling interval for --watch; after a failed poll it doubles, up to 2m or the interval if longer, until one succeeds")
+	output.AddFlags(GetPodCmd)
 }
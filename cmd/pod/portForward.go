@@ -0,0 +1,48 @@
+package pod
+
+import (
+	"cli/cmd/project"
+
+	"github.com/spf13/cobra"
+)
+
+var reverseForwards []string
+
+var PortForwardCmd = &cobra.Command{
+	Use:     "port-forward [podId|project] [LOCAL:REMOTE...]",
+	Args:    cobra.MinimumNArgs(1),
+	Short:   "forward local ports to a pod",
+	GroupID: "resource",
+	Long: `Tunnel local ports to ports on a pod over SSH, without going through the public proxy.
+
+Mappings are LOCAL:REMOTE; a single port uses the same number on both sides and
+":REMOTE" picks a free local port. Use -R LOCAL:REMOTE to expose a local port on the pod.
+
+Example:
+  podflow port-forward my-project 7270:7270 8888:8888
+  podflow port-forward abc123xyz :7270 -R 5432:5432`,
+	Run: func(cmd *cobra.Command, args []string) {
+		var forwards []project.Forward
+		for _, spec := range args[1:] {
+			f, err := project.ParseForward(spec, false)
+			cobra.CheckErr(err)
+			forwards = append(forwards, f)
+		}
+		for _, spec := range reverseForwards {
+			f, err := project.ParseForward(spec, true)
+			cobra.CheckErr(err)
+			forwards = append(forwards, f)
+		}
+		if len(forwards) == 0 {
+			cobra.CheckErr("no port mappings given")
+		}
+
+		podId, err := project.ResolvePod(args[0])
+		cobra.CheckErr(err)
+		cobra.CheckErr(project.PortForward(podId, forwards))
+	},
+}
+
+func init() {
+	PortForwardCmd.Flags().StringArrayVarP(&reverseForwards, "reverse", "R", nil, "expose a local port on the pod (LOCAL:REMOTE, repeatable)")
+}
@@ -8,7 +8,7 @@ import (
 )
 
 var RemovePodCmd = &cobra.Command{
-	Use:   "pod [podId]",
+	Use:   "rm [podId]",
 	Args:  cobra.ExactArgs(1),
 	Short: "remove a pod",
 	Long:  "remove a pod from runpod.io",
@@ -0,0 +1,23 @@
+package pod
+
+import (
+	"cli/cmd/project"
+
+	"github.com/spf13/cobra"
+)
+
+var forwardAgent bool
+
+var SSHPodCmd = &cobra.Command{
+	Use:   "ssh [podId]",
+	Args:  cobra.ExactArgs(1),
+	Short: "open a shell on a pod",
+	Long:  "open an interactive SSH session on a running pod",
+	Run: func(cmd *cobra.Command, args []string) {
+		cobra.CheckErr(project.OpenShell(args[0], forwardAgent))
+	},
+}
+
+func init() {
+	SSHPodCmd.Flags().BoolVarP(&forwardAgent, "forward-agent", "A", false, "forward the local SSH agent to the pod")
+}
@@ -10,7 +10,7 @@ import (
 var bidPerGpu float32
 
 var StartPodCmd = &cobra.Command{
-	Use:   "pod [podId]",
+	Use:   "start [podId]",
 	Args:  cobra.ExactArgs(1),
 	Short: "start a pod",
 	Long:  "start a pod from runpod.io",
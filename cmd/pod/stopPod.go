@@ -8,7 +8,7 @@ import (
 )
 
 var StopPodCmd = &cobra.Command{
-	Use:   "pod [podId]",
+	Use:   "stop [podId]",
 	Args:  cobra.ExactArgs(1),
 	Short: "stop a pod",
 	Long:  "stop a pod from runpod.io",
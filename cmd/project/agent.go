@@ -0,0 +1,233 @@
+package project
+
+import (
+	"bufio"
+	"bytes"
+	"cli/agent"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/fatih/color"
+	"github.com/pelletier/go-toml"
+)
+
+// The dev agent (package agent) prepares the environment and runs the API
+// server on the Pod in place of the scripts in scripts/. It is used when
+// this binary was built with it embedded ('make agent', then build with
+// -tags podflow_agent) for the Pod's architecture; otherwise the scripts run.
+
+// agent returns the path of the dev agent on the pod, uploading it on first
+// use, or "" when the scripts have to be used instead.
+func (sshConn *SSHConnection) agent() string {
+	sshConn.agentOnce.Do(func() {
+		agentPath, err := sshConn.installAgent()
+		if err != nil {
+			fmt.Printf("Unable to install the podflow agent, using scripts instead: %v\n", err)
+			return
+		}
+		sshConn.agentPath = agentPath
+	})
+	return sshConn.agentPath
+}
+
+// installAgent uploads the embedded agent for the pod's architecture unless
+// the same build is already there, which its name records.
+func (sshConn *SSHConnection) installAgent() (string, error) {
+	arch, err := sshConn.output("uname -m")
+	if err != nil {
+		return "", err
+	}
+	binary := embeddedAgent(strings.TrimSpace(arch))
+	if binary == nil {
+		return "", nil
+	}
+	sum := sha256.Sum256(binary)
+
+	client, err := sshConn.SFTP()
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+	home, err := client.Getwd()
+	if err != nil {
+		return "", fmt.Errorf("finding home directory: %w", err)
+	}
+	dir := path.Join(home, ".podflow", "bin")
+	agentPath := path.Join(dir, "podflow-agent-"+hex.EncodeToString(sum[:6]))
+	if _, err := client.Stat(agentPath); err == nil {
+		return agentPath, nil
+	}
+
+	fmt.Println("Uploading podflow agent to Pod...")
+	if err := client.MkdirAll(dir); err != nil {
+		return "", fmt.Errorf("creating %s: %w", dir, err)
+	}
+	partial := agentPath + partialSuffix
+	f, err := client.Create(partial)
+	if err != nil {
+		return "", fmt.Errorf("creating %s: %w", partial, err)
+	}
+	_, err = f.ReadFrom(bytes.NewReader(binary))
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("uploading agent: %w", err)
+	}
+	if err := client.Chmod(partial, 0755); err != nil {
+		return "", fmt.Errorf("making agent executable: %w", err)
+	}
+	if err := (remoteFS{client}).Rename(partial, agentPath); err != nil {
+		return "", fmt.Errorf("moving agent into place: %w", err)
+	}
+	return agentPath, nil
+}
+
+// agentConfig describes the project on the Pod, for the agent and for the
+// scripts that stand in for it.
+func agentConfig(config *toml.Tree, remoteProjectPath string) (agent.Config, error) {
+	projectID := config.GetPath([]string{"project", "uuid"}).(string)
+	packageManager, ok := config.GetPath([]string{"runtime", "package_manager"}).(string)
+	if !ok || packageManager == "" {
+		packageManager = "uv"
+	}
+	cfg := agent.Config{
+		ProjectDir: remoteProjectPath,
+		VenvPath:   "/" + path.Join(projectID, "venv"),
+		ArchivedVenvPath: path.Join(
+			config.GetPath([]string{"project", "volume_mount_path"}).(string),
+			projectID,
+			"dev-venv.tar.zst",
+		),
+		RequirementsPath: config.GetPath([]string{"runtime", "requirements_path"}).(string),
+		HandlerPath:      config.GetPath([]string{"runtime", "handler_path"}).(string),
+		PackageManager:   packageManager,
+		PythonVersion:    config.GetPath([]string{"runtime", "python_version"}).(string),
+		APIPort:          pickAPIPort(config),
+		TriggerFile:      devServerTrigger(projectID),
+	}
+
+	engine, err := selectSyncEngine()
+	if err != nil {
+		return cfg, err
+	}
+	if engine == syncEngineRsync {
+		cfg.Packages = append(cfg.Packages, "rsync")
+	}
+	return cfg, nil
+}
+
+// writeAgentConfig stores the agent's configuration next to the dev server's
+// other files and returns its path on the pod.
+func (sshConn *SSHConnection) writeAgentConfig(config *toml.Tree, remoteProjectPath string) (string, error) {
+	cfg, err := agentConfig(config, remoteProjectPath)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	client, err := sshConn.SFTP()
+	if err != nil {
+		return "", err
+	}
+	defer client.Close()
+	dir := devServerDir(config.GetPath([]string{"project", "uuid"}).(string))
+	if err := client.MkdirAll(dir); err != nil {
+		return "", fmt.Errorf("creating %s: %w", dir, err)
+	}
+	configFile := path.Join(dir, "agent.json")
+	f, err := client.Create(configFile)
+	if err != nil {
+		return "", fmt.Errorf("writing %s: %w", configFile, err)
+	}
+	_, err = f.Write(data)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return "", fmt.Errorf("writing %s: %w", configFile, err)
+	}
+	return configFile, nil
+}
+
+// runAgent runs an agent command to completion, printing its events.
+func (sshConn *SSHConnection) runAgent(agentPath string, command string, configFile string) error {
+	session, err := sshConn.Client().NewSession()
+	if err != nil {
+		return fmt.Errorf("failed to create SSH session: %w", err)
+	}
+	defer session.Close()
+
+	stdout, err := session.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+	stderr, err := session.StderrPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get stderr pipe: %w", err)
+	}
+	go scanAndPrint(stderr, color.New(color.FgRed), sshConn.podId, showPrefixInPodLogs)
+
+	if err := session.Start(withPodEnvironment(fmt.Sprintf("%s %s -config %s", shellQuote(agentPath), command, shellQuote(configFile)))); err != nil {
+		return fmt.Errorf("failed to start agent: %w", err)
+	}
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		sshConn.printAgentLine(scanner.Text())
+	}
+	if err := session.Wait(); err != nil {
+		return fmt.Errorf("agent %s failed: %w", command, err)
+	}
+	return nil
+}
+
+// printAgentLine prints a line of output from the dev server. Events from the
+// agent are printed the way the scripts' output is, with errors in red, and
+// any other line, such as the scripts' output itself, as it is.
+func (sshConn *SSHConnection) printAgentLine(line string) {
+	stdoutColor, stderrColor := color.New(color.FgGreen), color.New(color.FgRed)
+	event, ok := agent.ParseEvent(line)
+	if !ok {
+		sshConn.printPodLine(stdoutColor, line)
+		return
+	}
+	switch {
+	case event.Type == agent.EventError:
+		sshConn.printPodLine(stderrColor, stderrColor.Sprint("Error: "+event.Message))
+	case event.Stream == "stderr":
+		sshConn.printPodLine(stderrColor, event.Message)
+	default:
+		sshConn.printPodLine(stdoutColor, event.Message)
+	}
+}
+
+// printPodLine prints a line of output from the pod, prefixed with the pod ID
+// in prefixColor if --prefix-pod-logs is set.
+func (sshConn *SSHConnection) printPodLine(prefixColor *color.Color, line string) {
+	if showPrefixInPodLogs {
+		prefixColor.Printf("[%s] ", sshConn.podId)
+	}
+	fmt.Println(line)
+}
+
+// output runs command on the pod and returns what it printed.
+func (sshConn *SSHConnection) output(command string) (string, error) {
+	session, err := sshConn.Client().NewSession()
+	if err != nil {
+		return "", fmt.Errorf("failed to create SSH session: %w", err)
+	}
+	defer session.Close()
+	out, err := session.Output(command)
+	if err != nil {
+		return "", fmt.Errorf("failed to run command %q: %w", command, err)
+	}
+	return string(out), nil
+}
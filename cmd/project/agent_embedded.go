@@ -0,0 +1,24 @@
+//go:build podflow_agent
+
+package project
+
+import _ "embed"
+
+// Built by 'make agent'.
+var (
+	//go:embed agentbin/podflow-agent-linux-amd64
+	agentLinuxAmd64 []byte
+	//go:embed agentbin/podflow-agent-linux-arm64
+	agentLinuxArm64 []byte
+)
+
+// embeddedAgent returns the agent binary for arch as printed by uname -m.
+func embeddedAgent(arch string) []byte {
+	switch arch {
+	case "x86_64", "amd64":
+		return agentLinuxAmd64
+	case "aarch64", "arm64":
+		return agentLinuxArm64
+	}
+	return nil
+}
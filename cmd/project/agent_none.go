@@ -0,0 +1,9 @@
+//go:build !podflow_agent
+
+package project
+
+// embeddedAgent reports that this binary was built without the agent, so the
+// scripts are used on every Pod.
+func embeddedAgent(arch string) []byte {
+	return nil
+}
@@ -0,0 +1,247 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pelletier/go-toml"
+	"github.com/spf13/viper"
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/term"
+)
+
+// IdentityFiles lists the private keys to try, in order: identity_file from
+// the [ssh] table of runpod.toml in the current directory, sshIdentityFile
+// from the podflow config, and the key generated by 'podflow config ssh-key'.
+func IdentityFiles() []string {
+	var files []string
+	if config, err := toml.LoadFile("runpod.toml"); err == nil {
+		switch v := config.GetPath([]string{"ssh", "identity_file"}).(type) {
+		case string:
+			files = append(files, v)
+		case []interface{}:
+			for _, f := range v {
+				if s, ok := f.(string); ok {
+					files = append(files, s)
+				}
+			}
+		}
+	}
+	if f := viper.GetString("sshIdentityFile"); f != "" {
+		files = append(files, f)
+	}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		files = append(files, filepath.Join(homeDir, ".runpod", "ssh", "RunPod-Key-Go"))
+	}
+
+	seen := map[string]bool{}
+	var identities []string
+	for _, f := range files {
+		f = expandHome(f)
+		if !seen[f] {
+			seen[f] = true
+			identities = append(identities, f)
+		}
+	}
+	return identities
+}
+
+func expandHome(path string) string {
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		if homeDir, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(homeDir, path[1:])
+		}
+	}
+	return path
+}
+
+// sshAuth collects the signers offered to a pod and remembers which one the
+// server accepted, so that the ssh binary run by rsync can use the same key.
+type sshAuth struct {
+	signers []ssh.Signer
+	agent   net.Conn
+
+	mu   sync.Mutex
+	used string // identity file of the accepted key, "" for an agent key
+}
+
+// trackedSigner records the identity it came from when asked to sign, which
+// only happens once the server has accepted its public key.
+type trackedSigner struct {
+	ssh.Signer
+	source string
+	auth   *sshAuth
+}
+
+func (s *trackedSigner) Sign(rand io.Reader, data []byte) (*ssh.Signature, error) {
+	return s.SignWithAlgorithm(rand, data, "")
+}
+
+func (s *trackedSigner) SignWithAlgorithm(rand io.Reader, data []byte, algorithm string) (*ssh.Signature, error) {
+	s.auth.setUsed(s.source)
+	return signWithAlgorithm(s.Signer, rand, data, algorithm)
+}
+
+// signWithAlgorithm keeps the wrappers in this file usable as ssh.AlgorithmSigner,
+// without which RSA keys fall back to SHA-1 signatures that servers reject.
+func signWithAlgorithm(signer ssh.Signer, rand io.Reader, data []byte, algorithm string) (*ssh.Signature, error) {
+	if algorithmSigner, ok := signer.(ssh.AlgorithmSigner); ok {
+		return algorithmSigner.SignWithAlgorithm(rand, data, algorithm)
+	}
+	if algorithm != "" && algorithm != signer.PublicKey().Type() {
+		return nil, fmt.Errorf("signature algorithm %s is not supported by this key", algorithm)
+	}
+	return signer.Sign(rand, data)
+}
+
+func (a *sshAuth) setUsed(source string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.used = source
+}
+
+func (a *sshAuth) usedIdentity() string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.used
+}
+
+// newSSHAuth loads keys from the SSH agent, if SSH_AUTH_SOCK is set, followed
+// by the identity files that exist.
+func newSSHAuth() (*sshAuth, error) {
+	auth := &sshAuth{}
+
+	if socket := os.Getenv("SSH_AUTH_SOCK"); socket != "" {
+		conn, err := net.Dial("unix", socket)
+		if err == nil {
+			agentSigners, err := agent.NewClient(conn).Signers()
+			if err == nil {
+				auth.agent = conn
+				for _, signer := range agentSigners {
+					auth.signers = append(auth.signers, &trackedSigner{Signer: signer, auth: auth})
+				}
+			} else {
+				conn.Close()
+			}
+		}
+	}
+
+	var problems []string
+	for _, path := range IdentityFiles() {
+		signer, err := loadIdentity(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			problems = append(problems, err.Error())
+			continue
+		}
+		auth.signers = append(auth.signers, &trackedSigner{Signer: signer, source: path, auth: auth})
+	}
+
+	if len(auth.signers) == 0 {
+		auth.close()
+		if len(problems) > 0 {
+			return nil, fmt.Errorf("no usable SSH identity: %s", strings.Join(problems, "; "))
+		}
+		return nil, errors.New("no SSH identity found; run 'podflow config ssh-key' to create one")
+	}
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "Skipping SSH identity: %s\n", problem)
+	}
+	return auth, nil
+}
+
+func (a *sshAuth) method() ssh.AuthMethod {
+	return ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+		return a.signers, nil
+	})
+}
+
+func (a *sshAuth) close() {
+	if a.agent != nil {
+		a.agent.Close()
+	}
+}
+
+// loadIdentity reads a private key. Encrypted keys whose public half is known
+// are decrypted on first use, so the passphrase is only asked for when the
+// pod actually accepts that key.
+func loadIdentity(path string) (ssh.Signer, error) {
+	keyBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	signer, err := ssh.ParsePrivateKey(keyBytes)
+	var missing *ssh.PassphraseMissingError
+	if !errors.As(err, &missing) {
+		if err != nil {
+			return nil, fmt.Errorf("parsing private SSH key %s: %w", path, err)
+		}
+		return signer, nil
+	}
+
+	publicKey := missing.PublicKey
+	if publicKey == nil {
+		if pubBytes, err := os.ReadFile(path + ".pub"); err == nil {
+			publicKey, _, _, _, _ = ssh.ParseAuthorizedKey(pubBytes)
+		}
+	}
+	if publicKey == nil {
+		return decryptIdentity(path, keyBytes)
+	}
+	return &encryptedSigner{path: path, keyBytes: keyBytes, publicKey: publicKey}, nil
+}
+
+func decryptIdentity(path string, keyBytes []byte) (ssh.Signer, error) {
+	stdinFd := int(os.Stdin.Fd())
+	if !term.IsTerminal(stdinFd) {
+		return nil, fmt.Errorf("%s is encrypted and there is no terminal to ask for its passphrase", path)
+	}
+	fmt.Fprintf(os.Stderr, "Enter passphrase for %s: ", path)
+	passphrase, err := term.ReadPassword(stdinFd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return nil, fmt.Errorf("reading passphrase: %w", err)
+	}
+	signer, err := ssh.ParsePrivateKeyWithPassphrase(keyBytes, passphrase)
+	if err != nil {
+		return nil, fmt.Errorf("decrypting %s: %w", path, err)
+	}
+	return signer, nil
+}
+
+// encryptedSigner defers asking for a passphrase until a signature is needed.
+type encryptedSigner struct {
+	path      string
+	keyBytes  []byte
+	publicKey ssh.PublicKey
+
+	once   sync.Once
+	signer ssh.Signer
+	err    error
+}
+
+func (s *encryptedSigner) PublicKey() ssh.PublicKey {
+	return s.publicKey
+}
+
+func (s *encryptedSigner) Sign(rand io.Reader, data []byte) (*ssh.Signature, error) {
+	return s.SignWithAlgorithm(rand, data, "")
+}
+
+func (s *encryptedSigner) SignWithAlgorithm(rand io.Reader, data []byte, algorithm string) (*ssh.Signature, error) {
+	s.once.Do(func() {
+		s.signer, s.err = decryptIdentity(s.path, s.keyBytes)
+	})
+	if s.err != nil {
+		return nil, s.err
+	}
+	return signWithAlgorithm(s.signer, rand, data, algorithm)
+}
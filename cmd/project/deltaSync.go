@@ -0,0 +1,491 @@
+package project
+
+import (
+	"cli/agent"
+	"cli/ignore"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// The builtin sync engine only needs SFTP on the pod. A changed file that is
+// already on the pod is hashed there, a hash for every deltaChunkSize block,
+// and patched in place by sending only the blocks whose hashes differ from
+// the local file's. Next to each synced directory the engine keeps a
+// manifest of the files it wrote, so that their local deletion can be synced
+// without touching files created on the pod.
+const (
+	syncEngineRsync   = "rsync"
+	syncEngineBuiltin = "builtin"
+
+	deltaChunkSize  = agent.ChunkSize
+	manifestVersion = 1
+
+	// hashBatchSize is how many files are hashed on the pod per command.
+	hashBatchSize = 200
+)
+
+// selectSyncEngine returns the engine named by --sync-engine or, failing
+// that, by engine in the [sync] table of runpod.toml. Without either, rsync is
+// used when it is installed locally.
+func selectSyncEngine() (string, error) {
+	engine := syncEngine
+	if engine == "" {
+		engine, _ = syncSetting("engine").(string)
+	}
+	switch engine {
+	case syncEngineBuiltin, syncEngineRsync:
+		return engine, nil
+	case "":
+		if _, err := exec.LookPath("rsync"); err == nil {
+			return syncEngineRsync, nil
+		}
+		return syncEngineBuiltin, nil
+	}
+	return "", fmt.Errorf("unknown sync engine %q, expected %s or %s", engine, syncEngineBuiltin, syncEngineRsync)
+}
+
+// Push copies the files under localDir that the ignore rules keep to
+// remoteDir/<base of localDir> on the pod with the selected sync engine.
+func (sshConn *SSHConnection) Push(localDir string, remoteDir string, quiet bool) error {
+	engine, err := selectSyncEngine()
+	if err != nil {
+		return err
+	}
+	if engine == syncEngineRsync {
+		return sshConn.Rsync(localDir, remoteDir, quiet)
+	}
+	return sshConn.deltaSync(localDir, remoteDir, nil, nil, quiet)
+}
+
+// PushPaths is Push limited to paths relative to localDir, which matcher has
+// already filtered. Paths that no longer exist locally are deleted on the pod.
+func (sshConn *SSHConnection) PushPaths(localDir string, remoteDir string, paths []string, matcher *ignore.Matcher) error {
+	engine, err := selectSyncEngine()
+	if err != nil {
+		return err
+	}
+	if engine == syncEngineRsync {
+		return sshConn.RsyncPaths(localDir, remoteDir, paths)
+	}
+	return sshConn.deltaSync(localDir, remoteDir, matcher, paths, true)
+}
+
+// deltaSync runs the builtin engine, loading the ignore rules if matcher is nil.
+func (sshConn *SSHConnection) deltaSync(localDir string, remoteDir string, matcher *ignore.Matcher, paths []string, quiet bool) error {
+	if matcher == nil {
+		var err error
+		if matcher, err = loadSyncMatcher(localDir); err != nil {
+			return fmt.Errorf("getting ignore list: %w", err)
+		}
+	}
+	client, err := sshConn.SFTP()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	base := filepath.Base(localDir)
+	syncer := &deltaSyncer{
+		localDir:     localDir,
+		target:       remoteFS{client},
+		root:         path.Join(remoteDir, base),
+		manifestPath: path.Join(remoteDir, ".podflow-manifest-"+base+".json"),
+		ignore:       matcher,
+		quiet:        quiet,
+		hashRemote:   sshConn.chunkHashes,
+	}
+	return syncer.sync(paths)
+}
+
+// chunkHashes hashes the deltaChunkSize blocks of files on the pod, with the
+// agent if there is one and with a shell script otherwise.
+func (sshConn *SSHConnection) chunkHashes(files []string) (map[string][]string, error) {
+	var command string
+	if agentPath := sshConn.agent(); agentPath != "" {
+		command = ShellCommand(append([]string{agentPath, "hash"}, files...))
+	} else {
+		script, err := renderScript("hashChunks.sh", deltaChunkSize)
+		if err != nil {
+			return nil, err
+		}
+		command = ShellCommand(append([]string{"bash", "-c", script, "hashChunks"}, files...))
+	}
+	out, err := sshConn.output(command)
+	if err != nil {
+		return nil, err
+	}
+	return agent.ParseChunkHashes(strings.NewReader(out))
+}
+
+// syncTarget is a fileSystem the builtin sync engine can update in place.
+type syncTarget interface {
+	fileSystem
+	Lstat(name string) (fs.FileInfo, error)
+	OpenWriter(name string) (writerAtCloser, error)
+	Resize(name string, size int64) error
+	Chtimes(name string, mtime time.Time) error
+	RemoveAll(name string) error
+}
+
+type writerAtCloser interface {
+	io.WriterAt
+	io.Closer
+}
+
+func (localFS) Lstat(name string) (fs.FileInfo, error) { return os.Lstat(name) }
+
+func (localFS) OpenWriter(name string) (writerAtCloser, error) {
+	return os.OpenFile(name, os.O_WRONLY, 0)
+}
+
+func (localFS) Resize(name string, size int64) error       { return os.Truncate(name, size) }
+func (localFS) Chtimes(name string, mtime time.Time) error { return os.Chtimes(name, mtime, mtime) }
+func (localFS) RemoveAll(name string) error                { return os.RemoveAll(name) }
+
+func (r remoteFS) Lstat(name string) (fs.FileInfo, error) { return r.client.Lstat(name) }
+
+func (r remoteFS) OpenWriter(name string) (writerAtCloser, error) {
+	return r.client.OpenFile(name, os.O_WRONLY)
+}
+
+func (r remoteFS) Resize(name string, size int64) error { return r.client.Truncate(name, size) }
+
+func (r remoteFS) Chtimes(name string, mtime time.Time) error {
+	return r.client.Chtimes(name, mtime, mtime)
+}
+
+func (r remoteFS) RemoveAll(name string) error {
+	err := r.client.RemoveAll(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// syncManifest lists the files the engine has put on the pod.
+type syncManifest struct {
+	Version int             `json:"version"`
+	Files   map[string]bool `json:"files"`
+}
+
+// sameFile is the quick check rsync also uses: equal size and mtime.
+func sameFile(a, b fs.FileInfo) bool {
+	return a.Size() == b.Size() && a.ModTime().Unix() == b.ModTime().Unix()
+}
+
+type deltaSyncer struct {
+	localDir     string
+	target       syncTarget
+	root         string // copy of localDir on the target
+	manifestPath string
+	ignore       *ignore.Matcher
+	quiet        bool
+	// hashRemote returns the chunk hashes of files on the target, leaving
+	// out those it could not read.
+	hashRemote func(files []string) (map[string][]string, error)
+
+	manifest *syncManifest
+	dirs     map[string]bool // directories known to exist on the target
+	meter    *syncMeter
+	deleted  int
+}
+
+// sync brings the whole tree up to date when paths is nil, and otherwise
+// only the given paths, relative to localDir.
+func (s *deltaSyncer) sync(paths []string) error {
+	s.dirs = map[string]bool{}
+	s.manifest = s.loadManifest()
+
+	local := map[string]fs.FileInfo{}
+	remote := map[string]fs.FileInfo{}
+	var removed []string
+
+	if paths == nil {
+		if err := s.list(localFS{}, s.localDir, "", local); err != nil {
+			return fmt.Errorf("listing local files: %w", err)
+		}
+		if err := s.list(s.target, s.root, "", remote); err != nil {
+			return fmt.Errorf("listing files on the pod: %w", err)
+		}
+		// Only files this engine put on the pod are deleted with the
+		// local copy; anything created on the pod itself is left alone.
+		for rel := range s.manifest.Files {
+			if _, ok := local[rel]; ok {
+				continue
+			}
+			if s.ignore.Match(rel, false) {
+				delete(s.manifest.Files, rel)
+				continue
+			}
+			removed = append(removed, rel)
+		}
+	} else {
+		for _, rel := range paths {
+			rel = path.Clean(rel)
+			info, err := os.Lstat(filepath.Join(s.localDir, filepath.FromSlash(rel)))
+			switch {
+			case errors.Is(err, fs.ErrNotExist):
+				removed = append(removed, rel)
+			case err != nil:
+				return err
+			case s.ignore.Match(rel, info.IsDir()):
+			case info.IsDir():
+				if err := s.list(localFS{}, s.localDir, rel, local); err != nil {
+					return fmt.Errorf("listing local files: %w", err)
+				}
+				if err := s.list(s.target, s.root, rel, remote); err != nil {
+					return fmt.Errorf("listing files on the pod: %w", err)
+				}
+			case info.Mode().IsRegular():
+				local[rel] = info
+				if remoteInfo, err := s.target.Lstat(s.target.Join(s.root, rel)); err == nil {
+					remote[rel] = remoteInfo
+				}
+			}
+		}
+	}
+
+	for _, rel := range removed {
+		if err := s.remove(rel); err != nil {
+			return err
+		}
+	}
+
+	changed := map[string]int64{}
+	var patchable []string
+	for rel, info := range local {
+		r := remote[rel]
+		if r != nil && sameFile(info, r) {
+			s.manifest.Files[rel] = true
+			continue
+		}
+		changed[rel] = info.Size()
+		// Files of a single block are sent whole either way.
+		if r != nil && r.Size() > deltaChunkSize && info.Size() > deltaChunkSize {
+			patchable = append(patchable, rel)
+		}
+	}
+	if len(changed) > 0 {
+		warnLargeFiles(changed)
+		remoteChunks := s.remoteChunks(patchable)
+		var total int64
+		for _, size := range changed {
+			total += size
+		}
+		s.meter = newSyncMeter(len(changed), total, s.quiet)
+		for rel := range changed {
+			if err := s.push(rel, local[rel], remoteChunks[rel]); err != nil {
+				s.saveManifest()
+				return err
+			}
+		}
+		s.meter.finish()
+	}
+
+	if err := s.saveManifest(); err != nil {
+		return err
+	}
+	if !s.quiet && s.deleted > 0 {
+		fmt.Printf("Deleted %d files from the Pod\n", s.deleted)
+	}
+	return nil
+}
+
+// list adds the regular files below rel in the tree at root on fsys to files,
+// skipping ignored paths. Symlinks and other special files are not synced.
+func (s *deltaSyncer) list(fsys fileSystem, root string, rel string, files map[string]fs.FileInfo) error {
+	dir := root
+	if rel != "" {
+		dir = fsys.Join(root, rel)
+	}
+	entries, err := fsys.ReadDir(dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		p := path.Join(rel, entry.Name())
+		if s.ignore.Match(p, entry.IsDir()) {
+			continue
+		}
+		if entry.IsDir() {
+			if err := s.list(fsys, root, p, files); err != nil {
+				return err
+			}
+		} else if entry.Mode().IsRegular() {
+			files[p] = entry
+		}
+	}
+	return nil
+}
+
+func (s *deltaSyncer) remove(rel string) error {
+	if err := s.target.RemoveAll(s.target.Join(s.root, rel)); err != nil {
+		return fmt.Errorf("deleting %s: %w", rel, err)
+	}
+	for p := range s.manifest.Files {
+		if p == rel || strings.HasPrefix(p, rel+"/") {
+			delete(s.manifest.Files, p)
+		}
+	}
+	s.deleted++
+	return nil
+}
+
+// remoteChunks returns the chunk hashes of the target's copies of rels, or
+// of as many as could be hashed; the others are copied in full.
+func (s *deltaSyncer) remoteChunks(rels []string) map[string][]string {
+	chunks := map[string][]string{}
+	for start := 0; start < len(rels); start += hashBatchSize {
+		batch := rels[start:min(start+hashBatchSize, len(rels))]
+		files := make([]string, len(batch))
+		for i, rel := range batch {
+			files[i] = s.target.Join(s.root, rel)
+		}
+		hashes, err := s.hashRemote(files)
+		if err != nil {
+			fmt.Printf("Unable to hash files on the Pod, copying them in full: %v\n", err)
+			return chunks
+		}
+		for i, rel := range batch {
+			if h, ok := hashes[files[i]]; ok {
+				chunks[rel] = h
+			}
+		}
+	}
+	return chunks
+}
+
+// push updates the target's copy of rel by patching the blocks that differ
+// from remoteChunks, the hashes of the copy, or by copying rel in full when
+// there are none.
+func (s *deltaSyncer) push(rel string, info fs.FileInfo, remoteChunks []string) error {
+	src := filepath.Join(s.localDir, filepath.FromSlash(rel))
+	dst := s.target.Join(s.root, rel)
+
+	var err error
+	if remoteChunks != nil {
+		var chunks []string
+		if chunks, err = agent.ChunkHashes(src); err != nil {
+			return fmt.Errorf("reading %s: %w", src, err)
+		}
+		err = s.patch(src, dst, remoteChunks, chunks, info.Size())
+	} else {
+		err = s.copy(src, dst, info)
+	}
+	if err != nil {
+		return err
+	}
+	if err := s.target.Chmod(dst, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("setting mode of %s: %w", dst, err)
+	}
+	if err := s.target.Chtimes(dst, info.ModTime()); err != nil {
+		return fmt.Errorf("setting mtime of %s: %w", dst, err)
+	}
+	s.manifest.Files[rel] = true
+	return nil
+}
+
+func (s *deltaSyncer) copy(src string, dst string, info fs.FileInfo) error {
+	dir := path.Dir(dst)
+	if !s.dirs[dir] {
+		if err := s.target.MkdirAll(dir); err != nil {
+			return fmt.Errorf("creating directory %s: %w", dir, err)
+		}
+		s.dirs[dir] = true
+	}
+	// A partial file left by an earlier sync may hold another version.
+	s.target.Truncate(dst + partialSuffix)
+	return copyFile(meteredFS{localFS{}, s.meter}, src, info, s.target, dst, CopyOptions{Quiet: true})
+}
+
+// patch rewrites the blocks of dst whose hashes differ from src's.
+func (s *deltaSyncer) patch(src string, dst string, oldChunks []string, newChunks []string, size int64) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", src, err)
+	}
+	defer in.Close()
+	out, err := s.target.OpenWriter(dst)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", dst, err)
+	}
+
+	buf := make([]byte, deltaChunkSize)
+	for i, sum := range newChunks {
+		offset := int64(i) * deltaChunkSize
+		if i < len(oldChunks) && oldChunks[i] == sum {
+			s.meter.skip(min(deltaChunkSize, size-offset))
+			continue
+		}
+		n, err := in.ReadAt(buf, offset)
+		if err != nil && err != io.EOF {
+			out.Close()
+			return fmt.Errorf("reading %s: %w", src, err)
+		}
+		if _, err := out.WriteAt(buf[:n], offset); err != nil {
+			out.Close()
+			return fmt.Errorf("writing %s: %w", dst, err)
+		}
+		s.meter.add(n)
+	}
+	if err := out.Close(); err != nil {
+		return fmt.Errorf("writing %s: %w", dst, err)
+	}
+	if err := s.target.Resize(dst, size); err != nil {
+		return fmt.Errorf("truncating %s: %w", dst, err)
+	}
+	return nil
+}
+
+// loadManifest returns the manifest left by the last sync, or an empty one if
+// there is none or it cannot be read, which only leaves the files deleted
+// locally since on the pod.
+func (s *deltaSyncer) loadManifest() *syncManifest {
+	empty := &syncManifest{Version: manifestVersion, Files: map[string]bool{}}
+	f, err := s.target.Open(s.manifestPath)
+	if err != nil {
+		return empty
+	}
+	defer f.Close()
+
+	var manifest syncManifest
+	if err := json.NewDecoder(f).Decode(&manifest); err != nil || manifest.Version != manifestVersion || manifest.Files == nil {
+		return empty
+	}
+	return &manifest
+}
+
+func (s *deltaSyncer) saveManifest() error {
+	data, err := json.Marshal(s.manifest)
+	if err != nil {
+		return err
+	}
+	partial := s.manifestPath + partialSuffix
+	s.target.Truncate(partial)
+	out, err := s.target.OpenAppend(partial)
+	if err != nil {
+		return fmt.Errorf("writing sync manifest: %w", err)
+	}
+	_, err = out.Write(data)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing sync manifest: %w", err)
+	}
+	if err := s.target.Rename(partial, s.manifestPath); err != nil {
+		return fmt.Errorf("writing sync manifest: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,264 @@
+package project
+
+import (
+	"bytes"
+	"cli/agent"
+	"cli/ignore"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// syncTest runs the builtin engine in process, with a local directory as the
+// pod and the agent's hashing of that directory standing in for the pod's.
+type syncTest struct {
+	t      *testing.T
+	local  string
+	remote string
+	hashed [][]string // files hashed "on the pod", per call
+}
+
+func newSyncTest(t *testing.T) *syncTest {
+	dir := t.TempDir()
+	st := &syncTest{t: t, local: filepath.Join(dir, "project"), remote: filepath.Join(dir, "pod")}
+	os.MkdirAll(st.local, 0755)
+	os.MkdirAll(st.remote, 0755)
+	return st
+}
+
+func (st *syncTest) syncer() *deltaSyncer {
+	matcher, err := ignore.New([]string{"*.pyc", "cache/"})
+	if err != nil {
+		st.t.Fatal(err)
+	}
+	return &deltaSyncer{
+		localDir:     st.local,
+		target:       localFS{},
+		root:         filepath.Join(st.remote, "project"),
+		manifestPath: filepath.Join(st.remote, ".podflow-manifest-project.json"),
+		ignore:       matcher,
+		quiet:        true,
+		hashRemote: func(files []string) (map[string][]string, error) {
+			st.hashed = append(st.hashed, files)
+			var out bytes.Buffer
+			if err := agent.WriteChunkHashes(&out, files); err != nil {
+				return nil, err
+			}
+			return agent.ParseChunkHashes(&out)
+		},
+	}
+}
+
+// sync runs a sync and returns the bytes it sent.
+func (st *syncTest) sync(paths []string) int64 {
+	s := st.syncer()
+	if err := s.sync(paths); err != nil {
+		st.t.Fatal(err)
+	}
+	if s.meter == nil {
+		return 0
+	}
+	return s.meter.sent
+}
+
+// write writes a file and gives it a distinct mtime, as the sync compares
+// mtimes in seconds.
+func write(t *testing.T, name string, data []byte, mtime time.Time) {
+	os.MkdirAll(filepath.Dir(name), 0755)
+	if err := os.WriteFile(name, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(name, mtime, mtime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// chunked returns a block of data for each of fill, filled with it.
+func chunked(fill ...byte) []byte {
+	var data []byte
+	for _, b := range fill {
+		data = append(data, bytes.Repeat([]byte{b}, deltaChunkSize)...)
+	}
+	return data
+}
+
+func (st *syncTest) assertSynced(want map[string]string) {
+	st.t.Helper()
+	got := map[string]string{}
+	root := filepath.Join(st.remote, "project")
+	filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			rel, _ := filepath.Rel(root, p)
+			data, _ := os.ReadFile(p)
+			got[filepath.ToSlash(rel)] = string(data)
+		}
+		return nil
+	})
+	if !reflect.DeepEqual(got, want) {
+		st.t.Errorf("pod has %d files %v, want %d %v", len(got), keys(got), len(want), keys(want))
+		for k, v := range want {
+			if got[k] != v {
+				st.t.Errorf("%s differs (%d bytes, want %d)", k, len(got[k]), len(v))
+			}
+		}
+	}
+}
+
+func keys(m map[string]string) []string {
+	var ks []string
+	for k := range m {
+		ks = append(ks, k)
+	}
+	sort.Strings(ks)
+	return ks
+}
+
+func TestDeltaSync(t *testing.T) {
+	st := newSyncTest(t)
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	big := chunked('a', 'b', 'c')
+	write(t, filepath.Join(st.local, "main.py"), []byte("print('hi')\n"), t0)
+	write(t, filepath.Join(st.local, "data", "big.bin"), big, t0)
+	write(t, filepath.Join(st.local, "main.pyc"), []byte("ignored"), t0)
+	write(t, filepath.Join(st.local, "cache", "x"), []byte("ignored"), t0)
+
+	// The first sync copies everything that is not ignored
+	sent := st.sync(nil)
+	if want := int64(len(big) + len("print('hi')\n")); sent != want {
+		t.Errorf("first sync sent %d bytes, want %d", sent, want)
+	}
+	st.assertSynced(map[string]string{"main.py": "print('hi')\n", "data/big.bin": string(big)})
+	info, _ := os.Stat(filepath.Join(st.remote, "project", "data", "big.bin"))
+	if !info.ModTime().Equal(t0) {
+		t.Errorf("mtime on the pod %v, want %v", info.ModTime(), t0)
+	}
+
+	// Nothing changed: nothing is sent or hashed
+	st.hashed = nil
+	if sent := st.sync(nil); sent != 0 || len(st.hashed) != 0 {
+		t.Errorf("unchanged sync sent %d bytes and hashed %v", sent, st.hashed)
+	}
+
+	// A changed block of a large file is all that is sent
+	big = chunked('a', 'X', 'c')
+	write(t, filepath.Join(st.local, "data", "big.bin"), big, t0.Add(time.Minute))
+	if sent := st.sync(nil); sent != deltaChunkSize {
+		t.Errorf("sync of one changed block sent %d bytes, want %d", sent, deltaChunkSize)
+	}
+	st.assertSynced(map[string]string{"main.py": "print('hi')\n", "data/big.bin": string(big)})
+	if want := [][]string{{filepath.Join(st.remote, "project", "data", "big.bin")}}; !reflect.DeepEqual(st.hashed, want) {
+		t.Errorf("hashed %v on the pod, want %v", st.hashed, want)
+	}
+
+	// The pod's copy is what is compared against, even when it was changed
+	// on the pod behind the engine's back
+	write(t, filepath.Join(st.remote, "project", "data", "big.bin"), chunked('Y', 'X', 'c', 'd'), t0.Add(2*time.Minute))
+	if sent := st.sync(nil); sent != deltaChunkSize {
+		t.Errorf("sync against the changed copy sent %d bytes, want %d", sent, deltaChunkSize)
+	}
+	st.assertSynced(map[string]string{"main.py": "print('hi')\n", "data/big.bin": string(big)})
+
+	// The file shrinks
+	big = chunked('a', 'X')
+	write(t, filepath.Join(st.local, "data", "big.bin"), big[:len(big)-10], t0.Add(3*time.Minute))
+	st.sync(nil)
+	st.assertSynced(map[string]string{"main.py": "print('hi')\n", "data/big.bin": string(big[:len(big)-10])})
+
+	// Files deleted locally are deleted on the pod, files created there are kept
+	write(t, filepath.Join(st.remote, "project", "output.txt"), []byte("result"), t0)
+	os.Remove(filepath.Join(st.local, "main.py"))
+	st.sync(nil)
+	st.assertSynced(map[string]string{"output.txt": "result", "data/big.bin": string(big[:len(big)-10])})
+}
+
+func TestDeltaSyncPaths(t *testing.T) {
+	st := newSyncTest(t)
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	write(t, filepath.Join(st.local, "a.py"), []byte("a"), t0)
+	write(t, filepath.Join(st.local, "pkg", "b.py"), []byte("b"), t0)
+	write(t, filepath.Join(st.local, "c.py"), []byte("c"), t0)
+	st.sync(nil)
+
+	write(t, filepath.Join(st.local, "a.py"), []byte("a2"), t0.Add(time.Minute))
+	write(t, filepath.Join(st.local, "c.py"), []byte("c2"), t0.Add(time.Minute))
+	write(t, filepath.Join(st.local, "pkg", "new.py"), []byte("new"), t0)
+	write(t, filepath.Join(st.local, "pkg", "new.pyc"), []byte("ignored"), t0)
+	os.Remove(filepath.Join(st.local, "pkg", "b.py"))
+
+	// Only the given paths are looked at; c.py waits for the next full sync
+	st.sync([]string{"a.py", "pkg", "pkg/b.py", "pkg/new.pyc"})
+	st.assertSynced(map[string]string{"a.py": "a2", "c.py": "c", "pkg/new.py": "new"})
+}
+
+func TestDeltaSyncManifest(t *testing.T) {
+	st := newSyncTest(t)
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	// The manifest says which files the engine put on the pod, and so may
+	// delete there
+	manifest := `{"version":1,"files":{"gone.py":true}}`
+	write(t, filepath.Join(st.remote, ".podflow-manifest-project.json"), []byte(manifest), t0)
+	write(t, filepath.Join(st.remote, "project", "gone.py"), []byte("g"), t0)
+	write(t, filepath.Join(st.local, "a.py"), []byte("a"), t0)
+	st.sync(nil)
+	st.assertSynced(map[string]string{"a.py": "a"})
+
+	data, err := os.ReadFile(filepath.Join(st.remote, ".podflow-manifest-project.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var stored syncManifest
+	if err := json.Unmarshal(data, &stored); err != nil {
+		t.Fatal(err)
+	}
+	if want := (syncManifest{Version: manifestVersion, Files: map[string]bool{"a.py": true}}); !reflect.DeepEqual(stored, want) {
+		t.Errorf("manifest %+v, want %+v", stored, want)
+	}
+
+	// A manifest the engine cannot read lists no files, which are kept
+	write(t, filepath.Join(st.remote, ".podflow-manifest-project.json"), []byte(`{"version":9,"files":{"a.py":true}}`), t0)
+	os.Remove(filepath.Join(st.local, "a.py"))
+	st.sync(nil)
+	st.assertSynced(map[string]string{"a.py": "a"})
+}
+
+func TestHashChunksScript(t *testing.T) {
+	if _, err := exec.LookPath("bash"); err != nil {
+		t.Skip("bash is not installed")
+	}
+	dir := t.TempDir()
+	files := []string{
+		filepath.Join(dir, "empty"),
+		filepath.Join(dir, "small file"),
+		filepath.Join(dir, "big"),
+		filepath.Join(dir, "missing"),
+	}
+	os.WriteFile(files[0], nil, 0644)
+	os.WriteFile(files[1], []byte("hello"), 0644)
+	os.WriteFile(files[2], append(chunked('a', 'b'), 'c'), 0644)
+
+	script, err := renderScript("hashChunks.sh", deltaChunkSize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out, err := exec.Command("bash", append([]string{"-c", script, "hashChunks"}, files...)...).Output()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := agent.ParseChunkHashes(bytes.NewReader(out))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var agentOut bytes.Buffer
+	agent.WriteChunkHashes(&agentOut, files)
+	want, _ := agent.ParseChunkHashes(&agentOut)
+	if !reflect.DeepEqual(got, want) || len(want[files[2]]) != 3 {
+		t.Errorf("script hashed %v, agent %v", got, want)
+	}
+}
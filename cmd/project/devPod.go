@@ -0,0 +1,121 @@
+package project
+
+import (
+	"cli/api"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml"
+)
+
+// A pod given with 'podflow dev --pod' becomes the project's dev Pod until it
+// is gone. The association is kept with the sessions, in
+// ~/.runpod/sessions/<project id>.pod, and getProjectPod prefers it to the
+// Pod named after the project. Only such user-supplied Pods are recorded
+// there, which keeps them from being terminated at the end of a session.
+
+func projectPodFile(projectID string) (string, error) {
+	dir, err := sessionsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, projectID+".pod"), nil
+}
+
+// rememberedProjectPod returns the Pod chosen for the project, or "".
+func rememberedProjectPod(projectID string) string {
+	file, err := projectPodFile(projectID)
+	if err != nil {
+		return ""
+	}
+	data, err := os.ReadFile(file)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+func rememberProjectPod(projectID, podID string) error {
+	file, err := projectPodFile(projectID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(file), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(file, []byte(podID+"\n"), 0600)
+}
+
+// userSuppliedPod reports whether podID was given with --pod, now or for an
+// earlier session of the project, rather than launched by podflow.
+func userSuppliedPod(projectID, podID string) bool {
+	return podID != "" && (podID == devPodID || podID == rememberedProjectPod(projectID))
+}
+
+func forgetProjectPod(projectID string) {
+	if file, err := projectPodFile(projectID); err == nil {
+		os.Remove(file)
+	}
+}
+
+// validateDevPod checks that an existing Pod can host the project's dev
+// session: it must be running, reachable over SSH and mount its volume where
+// runpod.toml expects the project files.
+func validateDevPod(podID string, config *toml.Tree) error {
+	pods, err := api.GetPods()
+	if err != nil {
+		return fmt.Errorf("getting pods: %w", err)
+	}
+	var pod *api.Pod
+	for _, p := range pods {
+		if p.Id == podID {
+			pod = p
+		}
+	}
+	if pod == nil {
+		return fmt.Errorf("no pod with ID %q", podID)
+	}
+	if pod.DesiredStatus != "RUNNING" {
+		return fmt.Errorf("pod %s is %s, start it first", podID, strings.ToLower(pod.DesiredStatus))
+	}
+
+	if !podExposesPort(pod, 22, "tcp") {
+		return fmt.Errorf("pod %s does not expose SSH (22/tcp)", podID)
+	}
+
+	volumePath := config.GetPath([]string{"project", "volume_mount_path"}).(string)
+	if pod.VolumeMountPath == "" {
+		return fmt.Errorf("pod %s has no volume, the project needs one mounted at %s", podID, volumePath)
+	}
+	if pod.VolumeMountPath != volumePath {
+		return fmt.Errorf("pod %s mounts its volume at %s, but volume_mount_path in runpod.toml is %s", podID, pod.VolumeMountPath, volumePath)
+	}
+
+	if apiPort := pickAPIPort(config); !podExposesPort(pod, apiPort, "http") {
+		fmt.Printf("Pod %s does not expose %d/http, the API server will only be reachable with --forward\n", podID, apiPort)
+	}
+	return nil
+}
+
+// podExposesPort reports whether the pod exposes port, either in its
+// configuration ("22/tcp, 7270/http") or as a public port of the running
+// container.
+func podExposesPort(pod *api.Pod, port int, protocol string) bool {
+	want := strconv.Itoa(port) + "/" + protocol
+	for _, p := range strings.Split(pod.Ports, ",") {
+		if strings.TrimSpace(p) == want {
+			return true
+		}
+	}
+	if pod.Runtime != nil {
+		for _, p := range pod.Runtime.Ports {
+			if p.PrivatePort == port && p.Type == protocol {
+				return true
+			}
+		}
+	}
+	return false
+}
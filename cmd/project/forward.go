@@ -0,0 +1,163 @@
+package project
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+)
+
+// Forward maps a port on this machine to a port on the pod. Reverse forwards
+// listen on the pod and connect back to this machine instead.
+type Forward struct {
+	LocalPort  int
+	RemotePort int
+	Reverse    bool
+}
+
+func (f Forward) String() string {
+	if f.Reverse {
+		return fmt.Sprintf("pod localhost:%d -> localhost:%d", f.RemotePort, f.LocalPort)
+	}
+	return fmt.Sprintf("localhost:%d -> pod localhost:%d", f.LocalPort, f.RemotePort)
+}
+
+// ParseForward parses a LOCAL:REMOTE port mapping. A single port uses the same
+// number on both sides, and an empty local port (":7270") picks a free one.
+func ParseForward(spec string, reverse bool) (Forward, error) {
+	local, remote, found := strings.Cut(spec, ":")
+	if !found {
+		remote = local
+	}
+	f := Forward{Reverse: reverse}
+	var err error
+	if local != "" {
+		if f.LocalPort, err = parsePort(local); err != nil {
+			return f, fmt.Errorf("invalid mapping %q: %w", spec, err)
+		}
+	} else if reverse {
+		return f, fmt.Errorf("invalid mapping %q: reverse forwards need a local port", spec)
+	}
+	if f.RemotePort, err = parsePort(remote); err != nil {
+		return f, fmt.Errorf("invalid mapping %q: %w", spec, err)
+	}
+	return f, nil
+}
+
+func parsePort(s string) (int, error) {
+	port, err := strconv.Atoi(s)
+	if err != nil || port < 1 || port > 65535 {
+		return 0, fmt.Errorf("port %q must be a number between 1 and 65535", s)
+	}
+	return port, nil
+}
+
+// Forward starts tunneling f over the SSH connection and returns the listener,
+// which stops the forward when closed. For local forwards with no local port
+// the chosen port is written back to f.LocalPort.
+func (sshConn *SSHConnection) Forward(f *Forward) (net.Listener, error) {
+	var listener net.Listener
+	var dial func() (net.Conn, error)
+	var err error
+
+	if f.Reverse {
+		listener, err = sshConn.Client().Listen("tcp", fmt.Sprintf("localhost:%d", f.RemotePort))
+		if err != nil {
+			return nil, fmt.Errorf("listening on pod port %d: %w", f.RemotePort, err)
+		}
+		dial = func() (net.Conn, error) {
+			return net.Dial("tcp", fmt.Sprintf("localhost:%d", f.LocalPort))
+		}
+	} else {
+		listener, err = net.Listen("tcp", fmt.Sprintf("localhost:%d", f.LocalPort))
+		if err != nil {
+			return nil, fmt.Errorf("listening on local port %d: %w", f.LocalPort, err)
+		}
+		f.LocalPort = listener.Addr().(*net.TCPAddr).Port
+		dial = func() (net.Conn, error) {
+			return sshConn.Client().Dial("tcp", fmt.Sprintf("localhost:%d", f.RemotePort))
+		}
+	}
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				target, err := dial()
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Forward %s: %v\n", f, err)
+					return
+				}
+				defer target.Close()
+				pipe(conn, target)
+			}()
+		}
+	}()
+	return listener, nil
+}
+
+// pipe copies in both directions until either side is done.
+func pipe(a, b net.Conn) {
+	var wg sync.WaitGroup
+	wg.Add(2)
+	copyAndClose := func(dst, src net.Conn) {
+		defer wg.Done()
+		io.Copy(dst, src)
+		// Half-close so the other direction can drain; fall back to a full close.
+		if cw, ok := dst.(interface{ CloseWrite() error }); ok {
+			cw.CloseWrite()
+		} else {
+			dst.Close()
+		}
+	}
+	go copyAndClose(a, b)
+	go copyAndClose(b, a)
+	wg.Wait()
+}
+
+// PortForward connects to a pod and tunnels the given ports until interrupted.
+func PortForward(podId string, forwards []Forward) error {
+	sshConn, err := PodSSHConnection(podId)
+	if err != nil {
+		return err
+	}
+	defer sshConn.Close()
+
+	for i := range forwards {
+		listener, err := sshConn.Forward(&forwards[i])
+		if err != nil {
+			return err
+		}
+		defer listener.Close()
+		fmt.Printf("Forwarding %s\n", forwards[i])
+	}
+
+	// Local forwards dial through whichever client is current, but reverse
+	// forwards listen on the pod and have to be set up again after a reconnect.
+	sshConn.StayConnected()
+	sshConn.OnReconnect(func() {
+		for i := range forwards {
+			if !forwards[i].Reverse {
+				continue
+			}
+			if _, err := sshConn.Forward(&forwards[i]); err != nil {
+				fmt.Fprintf(os.Stderr, "Restoring forward %s: %v\n", forwards[i], err)
+			}
+		}
+	})
+
+	fmt.Println("Press Ctrl+C to stop forwarding.")
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	<-sigs
+	return nil
+}
@@ -1,6 +1,7 @@
 package project
 
 import (
+	"cli/agent"
 	"cli/api"
 	"embed"
 	"errors"
@@ -130,25 +131,112 @@ func getProjectPod(projectId string) (string, error) {
 	if err != nil {
 		return "ERROR", err
 	}
+	return selectProjectPod(pods, projectId)
+}
+
+// selectProjectPod returns the dev pod of the project among pods, like
+// getProjectPod.
+func selectProjectPod(pods []*api.Pod, projectId string) (string, error) {
+	// A pod chosen with 'podflow dev --pod' is used for as long as it exists
+	if podId := rememberedProjectPod(projectId); podId != "" {
+		for _, pod := range pods {
+			if pod.Id == podId {
+				return pod.Id, nil
+			}
+		}
+		forgetProjectPod(projectId)
+	}
+	options := findProjectPods(pods, projectId)
+	if len(options) == 0 {
+		return "", errors.New("pod does not exist for project")
+	}
+	podId, err := chooseProjectResource("dev Pods", options)
+	if err != nil {
+		return "ERROR", err
+	}
+	return podId, nil
+}
+
+// ResolvePod turns a pod ID or name, or a project uuid or name, into a pod
+// ID. An empty target selects the dev pod of the project in the current
+// directory. Projects are found by the labels of their pods, like 'podflow
+// dev' does.
+func ResolvePod(target string) (string, error) {
+	if target == "" {
+		if _, err := os.Stat("runpod.toml"); os.IsNotExist(err) {
+			return "", errors.New("no pod given and no 'runpod.toml' found in the current directory")
+		}
+		config := loadProjectConfig()
+		projectId, ok := config.GetPath([]string{"project", "uuid"}).(string)
+		if !ok || projectId == "" {
+			return "", errors.New("project uuid not found in runpod.toml")
+		}
+		podId, err := getProjectPod(projectId)
+		if podId == "" || podId == "ERROR" {
+			if err == nil {
+				err = errors.New("pod does not exist for project")
+			}
+			return "", fmt.Errorf("%w; start one with 'podflow dev'", err)
+		}
+		return podId, nil
+	}
+
+	pods, err := api.GetPods()
+	if err != nil {
+		return "", err
+	}
 	for _, pod := range pods {
-		if strings.Contains(pod.Name, projectId) {
+		if pod.Id == target || pod.Name == target {
 			return pod.Id, nil
 		}
 	}
-	return "", errors.New("pod does not exist for project")
+	if projectId := projectIdByName(pods, target); projectId != "" {
+		podId, err := selectProjectPod(pods, projectId)
+		if podId == "ERROR" {
+			return "", err
+		}
+		if podId != "" {
+			return podId, nil
+		}
+	}
+	return "", fmt.Errorf("no pod or project named %q", target)
 }
+
+// projectIdByName returns the uuid of the project target names: a project
+// uuid itself, the name of the project in the current directory, or the
+// project name in the name of a dev pod, "<project name>-dev (<uuid>)".
+func projectIdByName(pods []*api.Pod, target string) string {
+	for _, pod := range pods {
+		if IsProjectPod(pod, target) {
+			return target
+		}
+	}
+	if _, err := os.Stat("runpod.toml"); err == nil {
+		config := loadProjectConfig()
+		if name, _ := config.GetPath([]string{"name"}).(string); name == target {
+			if projectId, ok := config.GetPath([]string{"project", "uuid"}).(string); ok {
+				return projectId
+			}
+		}
+	}
+	for _, pod := range pods {
+		if strings.HasPrefix(pod.Name, target+"-dev (") {
+			if projectId := pod.EnvVar(projectIDEnv); projectId != "" {
+				return projectId
+			}
+		}
+	}
+	return ""
+}
+
+// getProjectEndpoint returns the endpoint the project is deployed to, or ""
+// if it has not been deployed yet.
 func getProjectEndpoint(projectId string) (string, error) {
 	endpoints, err := api.GetEndpoints()
 	if err != nil {
 		return "", err
 	}
-	for _, endpoint := range endpoints {
-		if strings.Contains(endpoint.Name, projectId) {
-			fmt.Println(endpoint.Id)
-			return endpoint.Id, nil
-		}
-	}
-	return "", errors.New("endpoint does not exist for project")
+	return chooseProjectResource("endpoints", findProjectEndpoints(endpoints, projectId))
 }
 
 func attemptPodLaunch(config *toml.Tree, networkVolumeId string, environmentVariables map[string]string, selectedGpuTypes []string) (pod map[string]interface{}, err error) {
@@ -191,7 +279,7 @@ func attemptPodLaunch(config *toml.Tree, networkVolumeId string, environmentVari
 func launchDevPod(config *toml.Tree, networkVolumeId string) (string, error) {
 	fmt.Println("Deploying project Pod on RunPod...")
 	//construct env vars
-	environmentVariables := createEnvVars(config)
+	environmentVariables := createEnvVars(config, roleDev)
 	// prepare gpu types
 	selectedGpuTypes := []string{}
 	tomlGpuTypes := config.GetPath([]string{"project", "gpu_types"})
@@ -214,7 +302,9 @@ func launchDevPod(config *toml.Tree, networkVolumeId string) (string, error) {
 	return new_pod["id"].(string), nil
 }
 
-func createEnvVars(config *toml.Tree) map[string]string {
+// createEnvVars returns the environment of a project resource, which also
+// identifies it as the project's, for role.
+func createEnvVars(config *toml.Tree, role string) map[string]string {
 	environmentVariables := map[string]string{}
 	tomlEnvVars := config.GetPath([]string{"project", "env_vars"})
 	if tomlEnvVars != nil {
@@ -223,7 +313,8 @@ func createEnvVars(config *toml.Tree) map[string]string {
 			environmentVariables[k] = v.(string)
 		}
 	}
-	environmentVariables["RUNPOD_PROJECT_ID"] = config.GetPath([]string{"project", "uuid"}).(string)
+	environmentVariables[projectIDEnv] = config.GetPath([]string{"project", "uuid"}).(string)
+	environmentVariables[api.LabelEnvPrefix+roleLabel] = role
 	return environmentVariables
 }
 
@@ -246,6 +337,9 @@ func formatAsDockerEnv(env map[string]string) string {
 func deployProject(networkVolumeId string) (endpointId string, err error) {
 	//parse project toml
 	config := loadProjectConfig()
+	if err := validateProjectConfig(config); err != nil {
+		return "", err
+	}
 	projectId := config.GetPath([]string{"project", "uuid"}).(string)
 	projectConfig := config.Get("project").(*toml.Tree)
 	projectName := config.Get("name").(string)
@@ -256,6 +350,9 @@ func deployProject(networkVolumeId string) (endpointId string, err error) {
 	//check for existing pod
 	fmt.Println("Finding a pod for initial file sync")
 	projectPodId, err := getProjectPod(projectId)
+	if projectPodId == "ERROR" {
+		return "", err
+	}
 	if projectPodId == "" || err != nil {
 		//or try to get pod with one of gpu types
 		projectPodId, err = launchDevPod(config, networkVolumeId)
@@ -270,25 +367,27 @@ func deployProject(networkVolumeId string) (endpointId string, err error) {
 		return "", err
 	}
 	//sync remote dev to remote prod
-	sshConn.RunCommand(fmt.Sprintf("mkdir -p %s", remoteProjectPath))
+	sshConn.RunCommand("mkdir -p " + shellQuote(remoteProjectPath))
 	fmt.Printf("Syncing files to Pod %s prod\n", projectPodId)
 	cwd, _ := os.Getwd()
-	sshConn.Rsync(cwd, projectPathUuidProd, false)
+	sshConn.Push(cwd, projectPathUuidProd, false)
 	//activate venv on remote
 	fmt.Printf("Activating Python virtual environment: %s on Pod %s\n", venvPath, projectPodId)
-	sshConn.RunCommands([]string{
-		fmt.Sprintf("python%s -m venv %s", config.GetPath([]string{"runtime", "python_version"}).(string), venvPath),
-		fmt.Sprintf(`source %s/bin/activate &&
-		cd %s &&
-		python -m pip install --upgrade pip &&
-		python -m pip install -v --requirement %s`,
-			venvPath, remoteProjectPath, config.GetPath([]string{"runtime", "requirements_path"}).(string)),
+	venvScript, err := renderScript("deployVenv.sh", agent.Config{
+		ProjectDir:       remoteProjectPath,
+		VenvPath:         venvPath,
+		RequirementsPath: config.GetPath([]string{"runtime", "requirements_path"}).(string),
+		PythonVersion:    config.GetPath([]string{"runtime", "python_version"}).(string),
 	})
-	env := mapToApiEnv(createEnvVars(config))
+	if err != nil {
+		return "", err
+	}
+	sshConn.RunCommand(venvScript)
+	env := mapToApiEnv(createEnvVars(config, roleProd))
 	// Construct the docker start command
 	handlerPath := path.Join(remoteProjectPath, config.GetPath([]string{"runtime", "handler_path"}).(string))
-	activateCmd := fmt.Sprintf(". %s/bin/activate", venvPath)
-	pythonCmd := fmt.Sprintf("python -u %s", handlerPath)
+	activateCmd := ". " + shellQuote(venvPath+"/bin/activate")
+	pythonCmd := "python -u " + shellQuote(handlerPath)
 	dockerStartCmd := "bash -c \"" + activateCmd + " && " + pythonCmd + "\""
 	//deploy new template
 	projectEndpointTemplateId, err := api.CreateTemplate(&api.CreateTemplateInput{
@@ -309,6 +408,10 @@ func deployProject(networkVolumeId string) (endpointId string, err error) {
 	}
 	//deploy / update endpoint
 	deployedEndpointId, err := getProjectEndpoint(projectId)
+	if err != nil {
+		fmt.Println("error finding project endpoint")
+		return "", err
+	}
 	//default endpoint settings
 	minWorkers := 0
 	maxWorkers := 3
@@ -333,7 +436,7 @@ func deployProject(networkVolumeId string) (endpointId string, err error) {
 			idleTimeout = int(idle)
 		}
 	}
-	if err != nil {
+	if deployedEndpointId == "" {
 		deployedEndpointId, err = api.CreateEndpoint(&api.CreateEndpointInput{
 			Name:            fmt.Sprintf("%s-endpoint-%s%s", projectName, projectId, flashbootSuffix),
 			TemplateId:      projectEndpointTemplateId,
@@ -375,7 +478,7 @@ func buildProjectDockerfile() {
 	//cmd: start handler
 	dockerfile = strings.ReplaceAll(dockerfile, "<<HANDLER_PATH>>", runtimeConfig.Get("handler_path").(string))
 	if includeEnvInDockerfile {
-		dockerEnv := formatAsDockerEnv(createEnvVars(config))
+		dockerEnv := formatAsDockerEnv(createEnvVars(config, roleProd))
 		dockerfile = strings.ReplaceAll(dockerfile, "<<SET_ENV_VARS>>", "\n"+dockerEnv)
 	} else {
 		dockerfile = strings.ReplaceAll(dockerfile, "<<SET_ENV_VARS>>", "")
@@ -1,12 +1,14 @@
 package project
 
 import (
-	"bufio"
+	"cli/ignore"
+	"fmt"
+	"io/fs"
 	"os"
 	"path/filepath"
 	"strings"
 
-	"github.com/gobwas/glob"
+	"github.com/spf13/cobra"
 )
 
 var EXCLUDE_PATTERNS = []string{
@@ -16,77 +18,103 @@ var EXCLUDE_PATTERNS = []string{
 	".git/",
 	"*.tmp",
 	"*.log",
+	"*" + partialSuffix,
 }
 
-func GetIgnoreList() ([]string, error) {
-	// Reads the .runpodignore file and returns a list of files to ignore.
-	ignoreList := make([]string, len(EXCLUDE_PATTERNS))
-	copy(ignoreList, EXCLUDE_PATTERNS)
+// ignoreFileName is read from the project root and any of its subdirectories.
+const ignoreFileName = ".runpodignore"
 
-	cwd, _ := os.Getwd()
-	ignoreFile := filepath.Join(cwd, ".runpodignore")
-
-	file, err := os.Open(ignoreFile)
+// loadSyncMatcher returns the rules deciding what is pushed from root:
+// EXCLUDE_PATTERNS, every .runpodignore file, the [sync] pull patterns, since
+// files pulled from the Pod are never pushed back, and last the files that
+// look like secrets.
+func loadSyncMatcher(root string) (*ignore.Matcher, error) {
+	matcher, err := ignore.Load(root, ignoreFileName, EXCLUDE_PATTERNS)
 	if err != nil {
-		if os.IsNotExist(err) {
-			return ignoreList, nil
-		}
 		return nil, err
 	}
-	defer file.Close()
+	if err := matcher.Add("runpod.toml [sync] pull", pullPatterns()); err != nil {
+		return nil, err
+	}
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := strings.TrimSpace(scanner.Text())
-		if line != "" && !strings.HasPrefix(line, "#") {
-			ignoreList = append(ignoreList, line)
+	var files []string
+	err = matcher.Walk(root, func(relPath string, d fs.DirEntry) error {
+		if d.Type().IsRegular() {
+			files = append(files, relPath)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	matcher, _, err = excludeSecrets(matcher, root, files)
+	return matcher, err
+}
 
-	if err := scanner.Err(); err != nil {
+// loadIgnoreMatcher returns the sync rules for the project in the current directory.
+func loadIgnoreMatcher() (*ignore.Matcher, error) {
+	cwd, err := os.Getwd()
+	if err != nil {
 		return nil, err
 	}
+	return loadSyncMatcher(cwd)
+}
 
-	return ignoreList, nil
+// ShouldIgnore reports whether filePath, absolute or relative to the current
+// directory, is excluded from syncing.
+func ShouldIgnore(filePath string) (bool, error) {
+	ignored, _, err := explainIgnore(filePath)
+	return ignored, err
 }
 
-func ShouldIgnore(filePath string, ignoreList []string) (bool, error) {
-	if ignoreList == nil {
-		var err error
-		ignoreList, err = GetIgnoreList()
-		if err != nil {
-			return false, err
-		}
+func explainIgnore(filePath string) (bool, *ignore.Rule, error) {
+	matcher, err := loadIgnoreMatcher()
+	if err != nil {
+		return false, nil, err
 	}
-
 	cwd, err := os.Getwd()
 	if err != nil {
-		return false, err
+		return false, nil, err
 	}
-
-	relativePath, err := filepath.Rel(cwd, filePath)
+	absPath, err := filepath.Abs(filePath)
 	if err != nil {
-		return false, err
+		return false, nil, err
+	}
+	relativePath, err := filepath.Rel(cwd, absPath)
+	if err != nil {
+		return false, nil, err
+	}
+	if relativePath == ".." || strings.HasPrefix(relativePath, ".."+string(filepath.Separator)) {
+		return false, nil, fmt.Errorf("%s is outside the project directory", filePath)
 	}
 
-	for _, pattern := range ignoreList {
-		if strings.HasPrefix(pattern, "/") {
-			pattern = pattern[1:]
-		}
-
-		if strings.HasSuffix(pattern, "/") {
-			pattern += "*"
-		}
-
-		glober, err := glob.Compile(pattern)
-		if err != nil {
-			return false, err
-		}
-
-		if glober.Match(relativePath) {
-			return true, nil
-		}
+	// Paths that do not exist yet are checked as files.
+	isDir := false
+	if info, err := os.Stat(absPath); err == nil {
+		isDir = info.IsDir()
 	}
+	ignored, rule := matcher.Explain(relativePath, isDir)
+	return ignored, rule, nil
+}
 
-	return false, nil
+var IgnoreCheckCmd = &cobra.Command{
+	Use:   "check [path...]",
+	Args:  cobra.MinimumNArgs(1),
+	Short: "Show whether paths are excluded from syncing",
+	Long:  "Show whether each path is excluded from syncing to the project Pod by the default patterns and .runpodignore files, and which pattern decided it.",
+	Run: func(cmd *cobra.Command, args []string) {
+		for _, arg := range args {
+			ignored, rule, err := explainIgnore(arg)
+			switch {
+			case err != nil:
+				fmt.Printf("%s: error: %v\n", arg, err)
+			case rule == nil:
+				fmt.Printf("%s: synced (no pattern matches)\n", arg)
+			case ignored:
+				fmt.Printf("%s: ignored by %s\n", arg, rule)
+			default:
+				fmt.Printf("%s: synced, re-included by %s\n", arg, rule)
+			}
+		}
+	},
 }
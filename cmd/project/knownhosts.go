@@ -0,0 +1,214 @@
+package project
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+)
+
+// Host keys are trusted on first use and remembered per pod in an OpenSSH
+// known_hosts file, so the ssh binary used by rsync can read the same file.
+// Each line carries the pod ID as its comment:
+//
+//	[1.2.3.4]:10022 ssh-ed25519 AAAA... abc123xyz
+//
+// ssh clients set up by 'podflow ssh config' accept new keys themselves, so
+// they get a file per pod in ~/.runpod/known_hosts.d instead, where the lines
+// they add cannot get in the way of the ones above.
+
+type hostKeyEntry struct {
+	host  string
+	key   ssh.PublicKey
+	podId string
+}
+
+func knownHostsPath() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".runpod", "known_hosts"), nil
+}
+
+func podKnownHostsDir() (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".runpod", "known_hosts.d"), nil
+}
+
+// PodKnownHostsFile returns the known_hosts file for ssh clients connecting
+// to podId, which PreparePodKnownHosts creates.
+func PodKnownHostsFile(podId string) (string, error) {
+	dir, err := podKnownHostsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, podId), nil
+}
+
+// PreparePodKnownHosts creates the known_hosts file of each of podIds that
+// has none, and removes those of other pods, which no longer exist. A new
+// pod never reuses an ID, so their keys are of no further use. A new file
+// holds the key podflow has already verified for the pod, if any, so that
+// the client checks against that key rather than accepting whatever it is
+// offered first.
+func PreparePodKnownHosts(podIds []string) error {
+	dir, err := podKnownHostsDir()
+	if err != nil {
+		return err
+	}
+	keep := map[string]bool{}
+	for _, podId := range podIds {
+		keep[podId] = true
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	for _, entry := range entries {
+		if !keep[entry.Name()] {
+			os.Remove(filepath.Join(dir, entry.Name()))
+		}
+	}
+
+	var shared []hostKeyEntry
+	if sharedPath, err := knownHostsPath(); err == nil {
+		shared, _ = loadKnownHosts(sharedPath)
+	}
+	for _, podId := range podIds {
+		path := filepath.Join(dir, podId)
+		if _, err := os.Stat(path); err == nil {
+			continue
+		}
+		var verified []hostKeyEntry
+		if i, found := findPodHostKey(shared, podId); found {
+			verified = append(verified, shared[i])
+		}
+		if err := saveKnownHosts(path, verified); err != nil {
+			return fmt.Errorf("writing %s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func loadKnownHosts(path string) ([]hostKeyEntry, error) {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+
+	var entries []hostKeyEntry
+	for len(data) > 0 {
+		_, hosts, key, comment, rest, err := ssh.ParseKnownHosts(data)
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		for _, host := range hosts {
+			entries = append(entries, hostKeyEntry{host: host, key: key, podId: comment})
+		}
+		data = rest
+	}
+	return entries, nil
+}
+
+func saveKnownHosts(path string, entries []hostKeyEntry) error {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		fmt.Fprintf(&buf, "%s %s\n", knownhosts.Line([]string{entry.host}, entry.key), entry.podId)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0600)
+}
+
+func findPodHostKey(entries []hostKeyEntry, podId string) (int, bool) {
+	for i, entry := range entries {
+		if entry.podId == podId {
+			return i, true
+		}
+	}
+	return -1, false
+}
+
+// podHostKeyAlgorithms pins the negotiated host key type to the one already
+// stored for the pod, so a server offering several keys is not mistaken for
+// one whose key has changed.
+func podHostKeyAlgorithms(podId string) []string {
+	path, err := knownHostsPath()
+	if err != nil {
+		return nil
+	}
+	entries, err := loadKnownHosts(path)
+	if err != nil {
+		return nil
+	}
+	i, found := findPodHostKey(entries, podId)
+	if !found {
+		return nil
+	}
+	keyType := entries[i].key.Type()
+	if keyType == ssh.KeyAlgoRSA {
+		return []string{ssh.KeyAlgoRSASHA512, ssh.KeyAlgoRSASHA256, ssh.KeyAlgoRSA}
+	}
+	return []string{keyType}
+}
+
+// podHostKeyCallback verifies the host key of podId against ~/.runpod/known_hosts.
+//
+// A pod seen for the first time has its key recorded, replacing any entry for
+// another pod at the same address, since addresses are reused when pods are
+// recreated. A known pod must present the same key; if it moved to a new
+// address with the same key, the entry is updated.
+func podHostKeyCallback(podId string) (ssh.HostKeyCallback, error) {
+	path, err := knownHostsPath()
+	if err != nil {
+		return nil, err
+	}
+	return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		host := knownhosts.Normalize(hostname)
+		entries, err := loadKnownHosts(path)
+		if err != nil {
+			return err
+		}
+
+		if i, found := findPodHostKey(entries, podId); found {
+			if !bytes.Equal(entries[i].key.Marshal(), key.Marshal()) {
+				return fmt.Errorf("host key for pod %s has changed (now %s %s); this could mean someone is intercepting the connection. "+
+					"If you are sure the pod was rebuilt, remove the lines ending in %q from %s",
+					podId, key.Type(), ssh.FingerprintSHA256(key), podId, path)
+			}
+			if entries[i].host == host {
+				return nil
+			}
+			entries[i].host = host
+			return saveKnownHosts(path, entries)
+		}
+
+		kept := entries[:0]
+		for _, entry := range entries {
+			if entry.host != host {
+				kept = append(kept, entry)
+			}
+		}
+		kept = append(kept, hostKeyEntry{host: host, key: key, podId: podId})
+		if err := saveKnownHosts(path, kept); err != nil {
+			return fmt.Errorf("saving host key: %w", err)
+		}
+		fmt.Printf("Added host key for pod %s (%s %s) to %s\n", podId, key.Type(), ssh.FingerprintSHA256(key), path)
+		return nil
+	}, nil
+}
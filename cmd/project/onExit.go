@@ -0,0 +1,144 @@
+package project
+
+import (
+	"cli/api"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/pelletier/go-toml"
+)
+
+// What happens to the dev Pod when a 'podflow dev' session ends, set with
+// --on-exit or [project] on_exit.
+const (
+	onExitKeep      = "keep"      // leave it running, e.g. for POD_INACTIVITY_TIMEOUT to stop
+	onExitStop      = "stop"      // stop it, keeping its volume disk
+	onExitTerminate = "terminate" // remove it
+)
+
+// exitPolicy returns what to do with the dev Pod at the end of the session.
+func exitPolicy(config *toml.Tree) (string, error) {
+	policy := onExit
+	if policy == "" {
+		policy, _ = config.GetPath([]string{"project", "on_exit"}).(string)
+	}
+	switch policy {
+	case "":
+		return onExitKeep, nil
+	case onExitKeep, onExitStop, onExitTerminate:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown on_exit %q, expected %s, %s or %s", policy, onExitKeep, onExitStop, onExitTerminate)
+}
+
+// sessionSignals catches interrupts from when a session has its Pod until
+// the session has ended, so that the Pod is always dealt with according to
+// the on exit policy. Interrupts after the first do not cut that short.
+type sessionSignals struct {
+	c           chan os.Signal
+	interrupted chan struct{} // closed on the first interrupt
+}
+
+func catchSessionSignals() *sessionSignals {
+	s := &sessionSignals{c: make(chan os.Signal, 1), interrupted: make(chan struct{})}
+	signal.Notify(s.c, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		if _, ok := <-s.c; !ok {
+			return
+		}
+		close(s.interrupted)
+		for range s.c {
+			fmt.Println("Ending the session, please wait...")
+		}
+	}()
+	return s
+}
+
+// stop restores the default handling of interrupts.
+func (s *sessionSignals) stop() {
+	signal.Stop(s.c)
+	close(s.c)
+}
+
+// devPodPolicy returns the policy for the dev Pod podID of the project.
+// A Pod given with --pod was not created by podflow, so it is stopped
+// instead of terminated unless --on-exit terminate says otherwise.
+func devPodPolicy(policy, projectID, podID string) string {
+	if policy != onExitTerminate || onExit == onExitTerminate || !userSuppliedPod(projectID, podID) {
+		return policy
+	}
+	fmt.Printf("Pod %s was not created by podflow, it will be stopped instead of terminated when the session ends; pass --on-exit terminate to terminate it.\n", podID)
+	return onExitStop
+}
+
+// flushVenvArchive stores the dev venv on the network volume, which the
+// server does in the background after installing requirements, so that a
+// stopped or new Pod does not have to reinstall them.
+func (sshConn *SSHConnection) flushVenvArchive(config *toml.Tree, remoteProjectPath string) error {
+	if agentPath := sshConn.agent(); agentPath != "" {
+		configFile, err := sshConn.writeAgentConfig(config, remoteProjectPath)
+		if err != nil {
+			return err
+		}
+		return sshConn.runAgent(agentPath, "archive", configFile)
+	}
+
+	cfg, err := agentConfig(config, remoteProjectPath)
+	if err != nil {
+		return err
+	}
+	archiveScript, err := renderScript("archiveVenv.sh", cfg)
+	if err != nil {
+		return err
+	}
+	return sshConn.RunCommand(archiveScript)
+}
+
+// endSession applies policy to the dev Pod once the API server has stopped,
+// and reports what the Pod cost since the session started. Without sshConn,
+// for a session interrupted while it was set up, the venv is not archived.
+func endSession(sshConn *SSHConnection, config *toml.Tree, podID, remoteProjectPath, policy string, started time.Time) {
+	var costPerHr float32
+	if pods, err := api.GetPods(); err == nil {
+		for _, pod := range pods {
+			if pod.Id == podID {
+				costPerHr = pod.CostPerHr
+			}
+		}
+	}
+
+	switch policy {
+	case onExitKeep:
+		fmt.Printf("Leaving Pod %s running.\n", podID)
+	case onExitStop, onExitTerminate:
+		if sshConn != nil {
+			fmt.Println("Syncing venv to network volume...")
+			if err := sshConn.flushVenvArchive(config, remoteProjectPath); err != nil {
+				fmt.Printf("Error syncing venv to network volume: %v\n", err)
+			}
+			sshConn.Close()
+		}
+
+		if policy == onExitStop {
+			fmt.Printf("Stopping Pod %s...\n", podID)
+			if _, err := api.StopPod(podID); err != nil {
+				fmt.Printf("Error stopping Pod %s: %v\n", podID, err)
+			}
+		} else {
+			fmt.Printf("Terminating Pod %s...\n", podID)
+			if _, err := api.RemovePod(podID); err != nil {
+				fmt.Printf("Error terminating Pod %s: %v\n", podID, err)
+			}
+		}
+	}
+
+	elapsed := time.Since(started).Round(time.Second)
+	if costPerHr > 0 {
+		fmt.Printf("Session cost: $%.3f (%s at $%.3f/hr)\n", float64(costPerHr)*elapsed.Hours(), elapsed, costPerHr)
+	} else {
+		fmt.Printf("Session lasted %s\n", elapsed)
+	}
+}
@@ -0,0 +1,61 @@
+package project
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestDevPodPolicy(t *testing.T) {
+	setHome(t)
+	if err := rememberProjectPod("abc", "team-pod"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name   string
+		policy string
+		flag   string // --on-exit
+		pod    string // --pod
+		podID  string
+		want   string
+	}{
+		{name: "own pod is terminated", policy: onExitTerminate, podID: "own-pod", want: onExitTerminate},
+		{name: "remembered pod is stopped", policy: onExitTerminate, podID: "team-pod", want: onExitStop},
+		{name: "given pod is stopped", policy: onExitTerminate, pod: "new-pod", podID: "new-pod", want: onExitStop},
+		{name: "explicit terminate", policy: onExitTerminate, flag: onExitTerminate, podID: "team-pod", want: onExitTerminate},
+		{name: "keep is kept", policy: onExitKeep, podID: "team-pod", want: onExitKeep},
+		{name: "stop is kept", policy: onExitStop, podID: "team-pod", want: onExitStop},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			onExit, devPodID = tt.flag, tt.pod
+			defer func() { onExit, devPodID = "", "" }()
+			if got := devPodPolicy(tt.policy, "abc", tt.podID); got != tt.want {
+				t.Errorf("devPodPolicy(%q) = %q, want %q", tt.policy, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSessionSignals(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("interrupts cannot be sent to a process on Windows")
+	}
+	signals := catchSessionSignals()
+	defer signals.stop()
+	self, _ := os.FindProcess(os.Getpid())
+
+	// Repeated interrupts while the session ends must not kill the process
+	for i := 0; i < 3; i++ {
+		if err := self.Signal(os.Interrupt); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case <-signals.interrupted:
+		case <-time.After(5 * time.Second):
+			t.Fatal("interrupt was not caught")
+		}
+	}
+	time.Sleep(100 * time.Millisecond)
+}
@@ -23,6 +23,13 @@ var (
 	setDefaultNetworkVolume bool
 	includeEnvInDockerfile  bool
 	showPrefixInPodLogs     bool
+	forwardAPIServer        bool
+	syncEngine              string
+	syncBandwidthLimit      int
+	onExit                  string
+	detachDev               bool
+	devPodID                string
+	followLogs              bool
 )
 
 // Define a struct that holds the display string and the corresponding value
@@ -200,6 +207,10 @@ var NewProjectCmd = &cobra.Command{
 			fmt.Print("Provide a name for your project:\n")
 			projectName = prompt("")
 		}
+		if !safeNamePattern.MatchString(projectName) {
+			fmt.Println("Project names may only contain letters, digits, spaces and . _ + @ -")
+			return
+		}
 		fmt.Print("\n   Project name set to '" + projectName + "'.\n\n")
 
 		// Project Examples
@@ -287,28 +298,104 @@ var StartProjectCmd = &cobra.Command{
 		config := loadProjectConfig()
 		projectId := config.GetPath([]string{"project", "uuid"}).(string)
 		networkVolumeId := viper.GetString(fmt.Sprintf("project_volumes.%s", projectId))
-		cachedNetVolExists := false
-		networkVolumes, err := api.GetNetworkVolumes()
-		if err == nil {
-			for _, networkVolume := range networkVolumes {
-				if networkVolume.Id == networkVolumeId {
-					cachedNetVolExists = true
+		// A Pod given with --pod brings its own volume
+		if devPodID == "" {
+			cachedNetVolExists := false
+			networkVolumes, err := api.GetNetworkVolumes()
+			if err == nil {
+				for _, networkVolume := range networkVolumes {
+					if networkVolume.Id == networkVolumeId {
+						cachedNetVolExists = true
+					}
 				}
 			}
-		}
-		if setDefaultNetworkVolume || networkVolumeId == "" || !cachedNetVolExists {
-			netVolId, err := selectNetworkVolume()
-			if err != nil {
-				return
+			if setDefaultNetworkVolume || networkVolumeId == "" || !cachedNetVolExists {
+				netVolId, err := selectNetworkVolume()
+				if err != nil {
+					return
+				}
+				networkVolumeId = netVolId
+				viper.Set(fmt.Sprintf("project_volumes.%s", projectId), networkVolumeId)
+				viper.WriteConfig()
 			}
-			networkVolumeId = netVolId
-			viper.Set(fmt.Sprintf("project_volumes.%s", projectId), networkVolumeId)
-			viper.WriteConfig()
 		}
-		startProject(networkVolumeId)
+		if err := startProject(networkVolumeId); err != nil {
+			fmt.Println(err)
+		}
+	},
+}
+
+var AttachCmd = &cobra.Command{
+	Use:     "attach [project]",
+	Args:    cobra.MaximumNArgs(1),
+	Short:   "Bring a detached development session to the foreground",
+	Long:    "Takes over file sync from the background process of a session started with 'podflow dev --detach' and prints the API server output, as 'podflow dev' does. The project defaults to the one in the current directory, or the only detached session.",
+	GroupID: "project",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := attachSession(firstArg(args)); err != nil {
+			fmt.Println(err)
+		}
+	},
+}
+
+var LogsCmd = &cobra.Command{
+	Use:     "logs [project]",
+	Args:    cobra.MaximumNArgs(1),
+	Short:   "Show the output of the development API server",
+	Long:    "Prints the output of the API server of a detached session, or of the project in the current directory, from its start. The project defaults to the one in the current directory, or the only detached session.",
+	GroupID: "project",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := showLogs(firstArg(args), followLogs); err != nil {
+			fmt.Println(err)
+		}
+	},
+}
+
+var SessionListCmd = &cobra.Command{
+	Use:     "ls",
+	Aliases: []string{"list"},
+	Args:    cobra.ExactArgs(0),
+	Short:   "List detached development sessions",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := printSessions(); err != nil {
+			fmt.Println(err)
+		}
+	},
+}
+
+var SessionStopCmd = &cobra.Command{
+	Use:   "stop [project]",
+	Args:  cobra.MaximumNArgs(1),
+	Short: "End a detached development session",
+	Long:  "Stops the file sync and the API server of a detached session, then keeps, stops or terminates its Pod as set by --on-exit or [project] on_exit when the session started.",
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := stopSession(firstArg(args)); err != nil {
+			fmt.Println(err)
+		}
+	},
+}
+
+// SessionSyncCmd is the background process of a detached session.
+var SessionSyncCmd = &cobra.Command{
+	Use:    "sync",
+	Args:   cobra.ExactArgs(0),
+	Short:  "Sync the project of a detached session",
+	Hidden: true,
+	Run: func(cmd *cobra.Command, args []string) {
+		if err := runSessionSync(); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
 	},
 }
 
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
 var DeployProjectCmd = &cobra.Command{
 	Use:   "deploy",
 	Args:  cobra.ExactArgs(0),
@@ -343,6 +430,33 @@ var DeployProjectCmd = &cobra.Command{
 	},
 }
 
+var PullProjectCmd = &cobra.Command{
+	Use:     "pull [pattern...]",
+	Short:   "Copy generated files from the project Pod",
+	Long:    "Copies files matching the [sync] pull patterns in runpod.toml, or the patterns given, from the project's dev Pod into the current folder. Local files are only replaced by newer copies from the Pod.",
+	GroupID: "project",
+	Run: func(cmd *cobra.Command, args []string) {
+		// Check for the existence of 'runpod.toml' in the current directory
+		if _, err := os.Stat("runpod.toml"); os.IsNotExist(err) {
+			fmt.Println("No 'runpod.toml' found in the current directory.")
+			fmt.Println("Please navigate to your project directory and try again.")
+			return
+		}
+
+		patterns := args
+		if len(patterns) == 0 {
+			patterns = pullPatterns()
+		}
+		if len(patterns) == 0 {
+			fmt.Println("Nothing to pull. Add patterns to pull in the [sync] table of runpod.toml or pass them as arguments.")
+			return
+		}
+		if err := pullProject(patterns); err != nil {
+			fmt.Println("Failed to pull files: ", err)
+		}
+	},
+}
+
 var PublishProjectCmd = &cobra.Command{
 	Use:   "publish",
 	Args:  cobra.ExactArgs(0),
@@ -393,5 +507,16 @@ func init() {
 	NewProjectCmd.Flags().StringVarP(&modelType, "type", "t", "", "Specify the model type for the project.")
 
 	StartProjectCmd.Flags().BoolVar(&showPrefixInPodLogs, "prefix-pod-logs", true, "Include the Pod ID as a prefix in log messages from the project Pod.")
+	StartProjectCmd.Flags().BoolVar(&forwardAPIServer, "forward", false, "Forward the API server to localhost over SSH.")
+	StartProjectCmd.Flags().StringVar(&syncEngine, "sync-engine", "", "Sync files with 'builtin' or 'rsync'. Defaults to [sync] engine in runpod.toml, then rsync if installed.")
+	DeployProjectCmd.Flags().StringVar(&syncEngine, "sync-engine", "", "Sync files with 'builtin' or 'rsync'. Defaults to [sync] engine in runpod.toml, then rsync if installed.")
+	StartProjectCmd.Flags().StringVar(&onExit, "on-exit", "", "What to do with the Pod when the session ends: 'keep', 'stop' or 'terminate'. Defaults to [project] on_exit in runpod.toml, then keep.")
+	StartProjectCmd.Flags().StringVar(&devPodID, "pod", "", "Use this existing Pod for the project instead of the project's own, here and in later commands until it is removed. It is never terminated at the end of a session unless --on-exit terminate is given.")
+	StartProjectCmd.Flags().BoolVarP(&detachDev, "detach", "d", false, "Leave the session running in the background once the API server is up. See 'podflow attach', 'podflow logs' and 'podflow session'.")
+	LogsCmd.Flags().BoolVarP(&followLogs, "follow", "f", false, "Keep printing output until the API server stops.")
+	SessionSyncCmd.Flags().StringVar(&syncEngine, "sync-engine", "", "Sync files with 'builtin' or 'rsync'.")
+	SessionSyncCmd.Flags().IntVar(&syncBandwidthLimit, "bwlimit", 0, "Limit file uploads to this many KiB/s.")
+	StartProjectCmd.Flags().IntVar(&syncBandwidthLimit, "bwlimit", 0, "Limit file uploads to this many KiB/s. Defaults to [sync] bwlimit in runpod.toml.")
+	DeployProjectCmd.Flags().IntVar(&syncBandwidthLimit, "bwlimit", 0, "Limit file uploads to this many KiB/s. Defaults to [sync] bwlimit in runpod.toml.")
 	BuildProjectCmd.Flags().BoolVar(&includeEnvInDockerfile, "include-env", false, "Incorporate environment variables defined in runpod.toml into the generated Dockerfile.")
 }
@@ -0,0 +1,159 @@
+package project
+
+import (
+	"cli/ignore"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/pelletier/go-toml"
+)
+
+// pullInterval is how often 'podflow dev' pulls while it runs.
+const pullInterval = 10 * time.Second
+
+// pullPatterns returns pull from the [sync] table of runpod.toml.
+func pullPatterns() []string {
+	return syncPatterns("pull")
+}
+
+// Pull copies the files below remoteDir/<base of localDir> on the pod that
+// match patterns into localDir, and returns how many it copied. A local file
+// is only replaced by a newer copy from the pod, and local files the pod no
+// longer has are kept.
+func (sshConn *SSHConnection) Pull(localDir string, remoteDir string, patterns []string, quiet bool) (int, error) {
+	matcher, err := ignore.New(patterns)
+	if err != nil {
+		return 0, err
+	}
+	client, err := sshConn.SFTP()
+	if err != nil {
+		return 0, err
+	}
+	defer client.Close()
+
+	remote := remoteFS{client}
+	remoteRoot := path.Join(remoteDir, filepath.Base(localDir))
+	pulled := 0
+
+	var walk func(rel string) error
+	walk = func(rel string) error {
+		entries, err := remote.ReadDir(path.Join(remoteRoot, rel))
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			p := path.Join(rel, entry.Name())
+			if entry.IsDir() {
+				if err := walk(p); err != nil {
+					return err
+				}
+				continue
+			}
+			if !entry.Mode().IsRegular() || strings.HasSuffix(p, partialSuffix) || !matcher.Match(p, false) {
+				continue
+			}
+
+			dst := filepath.Join(localDir, filepath.FromSlash(p))
+			if local, err := os.Stat(dst); err == nil {
+				if sameFile(local, entry) {
+					continue
+				}
+				if local.ModTime().After(entry.ModTime()) {
+					if !quiet {
+						fmt.Printf("Keeping local %s, it is newer than the copy on the Pod\n", p)
+					}
+					continue
+				}
+			}
+
+			if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+				return fmt.Errorf("creating directory for %s: %w", dst, err)
+			}
+			// A partial file left by an earlier pull may hold another version.
+			os.Remove(dst + partialSuffix)
+			if err := copyFile(remote, path.Join(remoteRoot, p), entry, localFS{}, dst, CopyOptions{Quiet: quiet}); err != nil {
+				return err
+			}
+			if err := os.Chtimes(dst, entry.ModTime(), entry.ModTime()); err != nil {
+				return fmt.Errorf("setting mtime of %s: %w", dst, err)
+			}
+			pulled++
+		}
+		return nil
+	}
+
+	err = walk("")
+	if errors.Is(err, fs.ErrNotExist) && pulled == 0 {
+		return 0, nil
+	}
+	return pulled, err
+}
+
+// pullPeriodically pulls every pullInterval until the returned function is
+// called, which waits for a pull in progress to finish.
+func (sshConn *SSHConnection) pullPeriodically(localDir string, remoteDir string, patterns []string) (stop func()) {
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(pullInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-quit:
+				return
+			case <-ticker.C:
+				if !sshConn.Connected() {
+					continue
+				}
+				n, err := sshConn.Pull(localDir, remoteDir, patterns, true)
+				if err != nil {
+					fmt.Printf("Error pulling files from Pod: %v\n", err)
+				} else if n > 0 {
+					fmt.Printf("Pulled %d files from Pod\n", n)
+				}
+			}
+		}
+	}()
+	return func() {
+		close(quit)
+		<-done
+	}
+}
+
+func pullProject(patterns []string) error {
+	config := loadProjectConfig()
+	projectConfig := config.Get("project").(*toml.Tree)
+	projectId := projectConfig.Get("uuid").(string)
+
+	podId, err := getProjectPod(projectId)
+	if podId == "" || podId == "ERROR" {
+		if err == nil {
+			err = errors.New("pod does not exist for project")
+		}
+		return fmt.Errorf("%w; start one with 'podflow dev'", err)
+	}
+	sshConn, err := PodSSHConnection(podId)
+	if err != nil {
+		return fmt.Errorf("failed to establish SSH: %w", err)
+	}
+	defer sshConn.Close()
+
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	projectPathDev := path.Join(projectConfig.Get("volume_mount_path").(string), projectId, "dev")
+	n, err := sshConn.Pull(cwd, projectPathDev, patterns, false)
+	if err != nil {
+		return fmt.Errorf("failed to pull files: %w", err)
+	}
+	fmt.Printf("Pulled %d files from Pod %s\n", n, podId)
+	return nil
+}
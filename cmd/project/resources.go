@@ -0,0 +1,99 @@
+package project
+
+import (
+	"cli/api"
+	"cli/cmd/nav"
+	"fmt"
+	"os"
+	"strings"
+
+	"golang.org/x/term"
+)
+
+// Pods and endpoints belong to a project by their environment rather than
+// their name, which can change: RUNPOD_PROJECT_ID holds the project's uuid
+// and the role label (PODFLOW_LABEL_role) says what the resource is for.
+const (
+	projectIDEnv = "RUNPOD_PROJECT_ID"
+	roleLabel    = "role"
+	roleDev      = "dev"  // the Pod of 'podflow dev'
+	roleProd     = "prod" // the endpoint of 'podflow deploy'
+)
+
+// IsProjectPod reports whether pod belongs to the project, in any role.
+func IsProjectPod(pod *api.Pod, projectID string) bool {
+	return pod.EnvVar(projectIDEnv) == projectID
+}
+
+// IsProjectEndpoint reports whether endpoint belongs to the project, in any
+// role.
+//
+// The API reports no environment at all for some endpoints deployed before
+// they were labeled. Only for those does the name 'podflow deploy' gave them
+// count; an endpoint with an environment but no RUNPOD_PROJECT_ID belongs to
+// no project, whatever its name.
+func IsProjectEndpoint(endpoint *api.Endpoint, projectID string) bool {
+	if len(endpoint.Env) > 0 {
+		return endpoint.EnvVar(projectIDEnv) == projectID
+	}
+	return strings.Contains(endpoint.Name, "-endpoint-"+projectID)
+}
+
+// resourceRole returns the role label of a resource, or unlabeled for one
+// from before the label existed, when each kind had a single role: Pods were
+// only made by 'podflow dev' and endpoints only by 'podflow deploy'.
+func resourceRole(labels map[string]string, unlabeled string) string {
+	if role, ok := labels[roleLabel]; ok {
+		return role
+	}
+	return unlabeled
+}
+
+// findProjectPods returns the project's dev Pods as options to choose from.
+func findProjectPods(pods []*api.Pod, projectID string) []nav.Option {
+	var options []nav.Option
+	for _, pod := range pods {
+		if IsProjectPod(pod, projectID) && resourceRole(pod.Labels(), roleDev) == roleDev {
+			options = append(options, nav.Option{
+				Name:  fmt.Sprintf("%s: %s (%s)", pod.Id, pod.Name, strings.ToLower(pod.DesiredStatus)),
+				Value: pod.Id,
+			})
+		}
+	}
+	return options
+}
+
+// findProjectEndpoints returns the project's deployed endpoints as options to
+// choose from.
+func findProjectEndpoints(endpoints []*api.Endpoint, projectID string) []nav.Option {
+	var options []nav.Option
+	for _, endpoint := range endpoints {
+		if IsProjectEndpoint(endpoint, projectID) && resourceRole(endpoint.Labels(), roleProd) == roleProd {
+			options = append(options, nav.Option{
+				Name:  fmt.Sprintf("%s: %s (workers %d-%d)", endpoint.Id, endpoint.Name, endpoint.WorkersMin, endpoint.WorkersMax),
+				Value: endpoint.Id,
+			})
+		}
+	}
+	return options
+}
+
+// chooseProjectResource returns the only option, or asks which one to use
+// when several resources claim to be the project's. Without a terminal to
+// ask on, several matches are an error.
+func chooseProjectResource(kind string, options []nav.Option) (string, error) {
+	switch len(options) {
+	case 0:
+		return "", nil
+	case 1:
+		return options[0].Value, nil
+	}
+	if !term.IsTerminal(int(os.Stdin.Fd())) {
+		ids := make([]string, len(options))
+		for i, option := range options {
+			ids[i] = option.Value
+		}
+		return "", fmt.Errorf("%d %s belong to the project (%s)", len(options), kind, strings.Join(ids, ", "))
+	}
+	return nav.SelectPrompt(fmt.Sprintf("Several %s belong to this project, select one:", kind), options)
+}
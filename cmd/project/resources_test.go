@@ -0,0 +1,111 @@
+package project
+
+import (
+	"cli/api"
+	"cli/cmd/nav"
+	"reflect"
+	"testing"
+)
+
+func podWithEnv(id, name string, env ...string) *api.Pod {
+	return &api.Pod{Id: id, Name: name, DesiredStatus: "RUNNING", Env: env}
+}
+
+func endpointWithEnv(id, name string, env map[string]string) *api.Endpoint {
+	endpoint := &api.Endpoint{Id: id, Name: name}
+	for k, v := range env {
+		endpoint.Env = append(endpoint.Env, &api.PodEnv{Key: k, Value: v})
+	}
+	return endpoint
+}
+
+func optionValues(options []nav.Option) []string {
+	var values []string
+	for _, option := range options {
+		values = append(values, option.Value)
+	}
+	return values
+}
+
+func TestFindProjectPods(t *testing.T) {
+	role := api.LabelEnvPrefix + roleLabel + "="
+	pods := []*api.Pod{
+		podWithEnv("dev", "renamed", projectIDEnv+"=abc", role+roleDev),
+		podWithEnv("legacy", "demo-dev (abc)", projectIDEnv+"=abc"),
+		podWithEnv("prod", "worker", projectIDEnv+"=abc", role+roleProd),
+		podWithEnv("other", "demo-dev (abc)", projectIDEnv+"=xyz", role+roleDev),
+		podWithEnv("named", "demo-dev (abc)"),
+	}
+	if got, want := optionValues(findProjectPods(pods, "abc")), []string{"dev", "legacy"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("findProjectPods = %v, want %v", got, want)
+	}
+}
+
+func TestFindProjectEndpoints(t *testing.T) {
+	role := api.LabelEnvPrefix + roleLabel
+	endpoints := []*api.Endpoint{
+		endpointWithEnv("prod", "renamed", map[string]string{projectIDEnv: "abc", role: roleProd}),
+		endpointWithEnv("legacy", "x", map[string]string{projectIDEnv: "abc"}),
+		endpointWithEnv("dev", "x", map[string]string{projectIDEnv: "abc", role: roleDev}),
+		endpointWithEnv("unreported", "demo-endpoint-abc-1714564800000", nil),
+		endpointWithEnv("unlabeled", "demo-endpoint-abc-1714564800000", map[string]string{"OTHER": "1"}),
+		endpointWithEnv("other", "demo-endpoint-xyz", nil),
+	}
+	if got, want := optionValues(findProjectEndpoints(endpoints, "abc")), []string{"prod", "legacy", "unreported"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("findProjectEndpoints = %v, want %v", got, want)
+	}
+}
+
+func TestSelectProjectPod(t *testing.T) {
+	setHome(t)
+	role := api.LabelEnvPrefix + roleLabel + "=" + roleDev
+	pods := []*api.Pod{
+		podWithEnv("dev1", "demo-dev (abc)", projectIDEnv+"=abc", role),
+		podWithEnv("team", "a100"),
+		podWithEnv("solo", "solo-dev (one)", projectIDEnv+"=one", role),
+	}
+
+	if got, err := selectProjectPod(pods, "one"); got != "solo" || err != nil {
+		t.Errorf("selectProjectPod(one) = %q, %v; want solo", got, err)
+	}
+	if got, err := selectProjectPod(pods, "none"); got != "" || err == nil {
+		t.Errorf("selectProjectPod(none) = %q, %v; want an error", got, err)
+	}
+
+	// A pod given with --pod wins while it exists
+	rememberProjectPod("abc", "team")
+	if got, _ := selectProjectPod(pods, "abc"); got != "team" {
+		t.Errorf("selectProjectPod with a remembered pod = %q, want team", got)
+	}
+	rememberProjectPod("abc", "gone")
+	if got, _ := selectProjectPod(pods, "abc"); got != "dev1" || rememberedProjectPod("abc") != "" {
+		t.Errorf("selectProjectPod with a removed pod = %q, remembered %q; want dev1 and forgotten", got, rememberedProjectPod("abc"))
+	}
+
+	// Without a terminal, several matches are an error rather than a guess
+	pods = append(pods, podWithEnv("dev2", "copy", projectIDEnv+"=abc", role))
+	if got, err := selectProjectPod(pods, "abc"); got != "ERROR" || err == nil {
+		t.Errorf("selectProjectPod with two pods = %q, %v; want an error", got, err)
+	}
+}
+
+func TestProjectIdByName(t *testing.T) {
+	pods := []*api.Pod{
+		podWithEnv("p1", "demo-dev (abc)", projectIDEnv+"=abc"),
+		podWithEnv("p2", "legacy-dev (old)"),
+	}
+	tests := []struct {
+		target string
+		want   string
+	}{
+		{"abc", "abc"},
+		{"demo", "abc"},
+		{"legacy", ""},
+		{"nope", ""},
+	}
+	for _, tt := range tests {
+		if got := projectIdByName(pods, tt.target); got != tt.want {
+			t.Errorf("projectIdByName(%q) = %q, want %q", tt.target, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package project
+
+import (
+	"embed"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+
+	"github.com/pelletier/go-toml"
+)
+
+// The shell scripts run on the Pod are templates in scripts/. Every value
+// from the project config goes through the quote function, which makes it a
+// single shell word, and validateProjectConfig rejects values that would
+// need quoting anywhere else, such as in a template name or an endpoint's
+// start command.
+
+//go:embed scripts/*.sh
+var scriptFiles embed.FS
+
+var scriptTemplates = template.Must(
+	template.New("scripts").
+		Funcs(template.FuncMap{"quote": shellQuote}).
+		Option("missingkey=error").
+		ParseFS(scriptFiles, "scripts/*.sh"),
+)
+
+// renderScript renders the script in scripts/name with data.
+func renderScript(name string, data interface{}) (string, error) {
+	var script strings.Builder
+	if err := scriptTemplates.ExecuteTemplate(&script, name, data); err != nil {
+		return "", fmt.Errorf("rendering %s: %w", name, err)
+	}
+	return script.String(), nil
+}
+
+var (
+	safeNamePattern    = regexp.MustCompile(`^[A-Za-z0-9 ._+@-]+$`)
+	safePathPattern    = regexp.MustCompile(`^[A-Za-z0-9 ._+@/-]+$`)
+	safeVersionPattern = regexp.MustCompile(`^[0-9.]*$`)
+)
+
+// validateProjectConfig checks the values of runpod.toml that end up in
+// paths and commands on the Pod against a safe set of characters.
+func validateProjectConfig(config *toml.Tree) error {
+	checks := []struct {
+		key     []string
+		pattern *regexp.Regexp
+		allowed string
+	}{
+		{[]string{"name"}, safeNamePattern, "letters, digits, spaces and . _ + @ -"},
+		{[]string{"project", "uuid"}, safeNamePattern, "letters, digits, spaces and . _ + @ -"},
+		{[]string{"project", "volume_mount_path"}, safePathPattern, "letters, digits, spaces and . _ + @ - /"},
+		{[]string{"runtime", "handler_path"}, safePathPattern, "letters, digits, spaces and . _ + @ - /"},
+		{[]string{"runtime", "requirements_path"}, safePathPattern, "letters, digits, spaces and . _ + @ - /"},
+		{[]string{"runtime", "python_version"}, safeVersionPattern, "digits and ."},
+	}
+	for _, check := range checks {
+		value, ok := config.GetPath(check.key).(string)
+		if !ok {
+			continue
+		}
+		if !check.pattern.MatchString(value) {
+			return fmt.Errorf("%s = %q in runpod.toml may only contain %s", strings.Join(check.key, "."), value, check.allowed)
+		}
+	}
+
+	if packageManager, ok := config.GetPath([]string{"runtime", "package_manager"}).(string); ok {
+		switch packageManager {
+		case "", "uv", "pip":
+		default:
+			return fmt.Errorf("runtime.package_manager = %q in runpod.toml must be uv or pip", packageManager)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,12 @@
+#!/bin/bash
+# Stores the dev venv on the network volume before the Pod stops, so that
+# the next Pod restores it instead of reinstalling the requirements.
+# Rendered by renderScript from the project's agent.Config.
+set -euo pipefail
+
+if [ ! -f {{quote .VenvPath}}/bin/activate ]; then
+	exit 0
+fi
+tar -c -C {{quote .VenvPath}} . | zstd -T0 -q > {{quote .ArchivedVenvPath}}.part
+mv {{quote .ArchivedVenvPath}}.part {{quote .ArchivedVenvPath}}
+echo "Synced venv to network volume"
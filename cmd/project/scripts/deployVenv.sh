@@ -0,0 +1,11 @@
+#!/bin/bash
+# Creates the production venv on the network volume and installs the
+# project's requirements into it. Rendered by renderScript from the
+# agent.Config of the prod copy in deployProject.
+set -euo pipefail
+
+python{{quote .PythonVersion}} -m venv {{quote .VenvPath}}
+source {{quote .VenvPath}}/bin/activate
+cd {{quote .ProjectDir}}
+python -m pip install --upgrade pip
+python -m pip install -v --requirement {{quote .RequirementsPath}}
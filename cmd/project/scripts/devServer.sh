@@ -0,0 +1,169 @@
+#!/bin/bash
+# Runs the API server during development, restarting it after every sync.
+# Rendered by renderScript from the project's agent.Config.
+set -euo pipefail
+IFS=$'\n\t'
+
+# Error handler to capture the failing command and its line number.
+error_handler() {
+	local exit_code=$?
+	local line_number=${BASH_LINENO[0]}
+	echo "Error: Command '${BASH_COMMAND}' exited with code ${exit_code} at line ${line_number}." >&2
+	exit "${exit_code}"
+}
+trap 'error_handler' ERR
+
+API_PORT={{.APIPort}}
+API_HOST="0.0.0.0"
+PACKAGE_MANAGER={{quote .PackageManager}}		# Specified in the project config
+PYTHON_VENV_PATH={{quote .VenvPath}}			# Path to the Python virtual environment used during development located on the Pod at /<project_id>/venv
+ARCHIVED_VENV_PATH={{quote .ArchivedVenvPath}}
+REQUIRED_FILES={{quote .RequirementsPath}}		# Relative to PROJECT_DIRECTORY
+HANDLER_PATH={{quote .HandlerPath}}				# Relative to PROJECT_DIRECTORY
+PROJECT_DIRECTORY={{quote .ProjectDir}}
+TRIGGER_FILE={{quote .TriggerFile}}
+
+if [ -z "${BASE_RELEASE_VERSION}" ]; then
+	PRINTED_API_PORT=$API_PORT
+else
+	API_PORT=7271
+	PRINTED_API_PORT=7270
+fi
+
+# Change to the project directory
+if cd "$PROJECT_DIRECTORY"; then
+	echo -e "- Changed to project directory."
+else
+	echo "Failed to change directory."
+	exit 1
+fi
+
+# --- Functions ---
+
+function start_api_server {
+	# Kill any process listening on API_PORT
+			lsof -ti:"$API_PORT" | xargs --no-run-if-empty kill -9 2>/dev/null || true
+	# Start the API server in the background
+	python "$1" --rp_serve_api --rp_api_host="$API_HOST" --rp_api_port=$API_PORT --rp_api_concurrency=1 &
+	SERVER_PID=$!
+}
+
+
+wait_for_pid() {
+	local pid="$1"
+	local timeout="$2"
+	for ((i = 0; i < timeout; i++)); do
+		# kill -0 doesn't send a signal but checks for the existence of the process.
+		if ! kill -0 "$pid" 2>/dev/null; then
+			return 0  # Process is gone.
+		fi
+		sleep 1
+	done
+	return 1  # Timed out waiting for the process to disappear.
+}
+
+force_kill() {
+	local pid="$1"
+
+	if [[ -z "$pid" ]]; then
+		echo "No PID provided for force_kill." >&2
+		return 1
+	fi
+
+	# Attempt graceful termination (SIGTERM)
+	kill "$pid" 2>/dev/null
+
+	if wait_for_pid "$pid" 5; then
+		echo "Process $pid has been gracefully terminated."
+		return 0
+	fi
+
+	echo "Graceful kill failed, attempting SIGKILL..."
+	kill -9 "$pid" 2>/dev/null
+
+	if wait_for_pid "$pid" 5; then
+		echo "Process $pid has been killed with SIGKILL."
+		return 0
+	fi
+
+	echo "Failed to kill process with PID: $pid after SIGKILL attempt." >&2
+	return 1
+}
+
+
+function tar_venv {
+	# Archive the virtual environment and move it to the network volume.
+			tar -c -C "$PYTHON_VENV_PATH" . | zstd -T0 > /venv.tar.zst
+			mv /venv.tar.zst "$ARCHIVED_VENV_PATH"
+			echo "Synced venv to network volume"
+}
+
+# Run tar_venv in the background initially.
+tar_venv &
+
+
+function cleanup {
+	echo "Cleaning up..."
+	force_kill "$SERVER_PID"
+}
+trap cleanup EXIT SIGINT SIGTERM
+
+
+# The CLI applies .runpodignore before syncing and then writes the synced
+# paths to TRIGGER_FILE, so every write to it is a change worth a restart.
+monitor_and_restart() {
+	local requirements_sum new_requirements_sum changed_files
+
+	requirements_sum=$(md5sum "$REQUIRED_FILES" 2>/dev/null || true)
+	touch "$TRIGGER_FILE"
+
+	while read -r _; do
+		# Let a burst of syncs settle before restarting.
+		while read -r -t 0.5 _; do :; done
+
+		changed_files=$(cat "$TRIGGER_FILE")
+		echo "Found changes in: ${changed_files//$'\n'/ }"
+
+		# Kill the current server.
+		force_kill "$SERVER_PID"
+
+		# If the requirements file changed, update the environment.
+		new_requirements_sum=$(md5sum "$REQUIRED_FILES" 2>/dev/null || true)
+		if [[ "$new_requirements_sum" != "$requirements_sum" ]]; then
+			requirements_sum=$new_requirements_sum
+			echo "Installing new requirements..."
+			if [ "$PACKAGE_MANAGER" == "pip" ]; then
+				python -m pip install --upgrade pip && python -m pip install -v --requirement "$REQUIRED_FILES" --report /installreport.json hf_transfer
+			elif [ "$PACKAGE_MANAGER" == "uv" ]; then
+				uv pip install --requirement "$REQUIRED_FILES" hf_transfer
+			else
+				echo "[ERROR] Unsupported package manager: $PACKAGE_MANAGER"
+			fi
+
+			# Tar the venv and sync it to the network volume in the background
+			tar_venv &
+		fi
+
+		# Restart the API server in the background, and save the PID
+		start_api_server "$HANDLER_PATH"
+		echo "Restarted API server with PID: $SERVER_PID"
+	done < <(inotifywait -m -q -e close_write "$TRIGGER_FILE")
+}
+
+# --- Main Execution ---
+
+if source "$PYTHON_VENV_PATH/bin/activate"; then
+	echo -e "- Activated project environment."
+else
+	echo "Failed to activate project environment." >&2
+	exit 1
+fi
+
+start_api_server "$HANDLER_PATH"
+echo -e "- Started API server with PID: $SERVER_PID"
+echo ""
+echo "Connect to the API server at:"
+echo ">  https://$RUNPOD_POD_ID-$PRINTED_API_PORT.proxy.runpod.net"
+echo ""
+
+monitor_and_restart
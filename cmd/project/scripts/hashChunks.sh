@@ -0,0 +1,16 @@
+#!/bin/bash
+# Prints "<index> <sha256> <path>" for every chunk of each file given as an
+# argument, the output of 'podflow-agent hash', on Pods without the agent.
+# Files that cannot be read are left out.
+# Rendered by renderScript with the chunk size in bytes.
+
+for f in "$@"; do
+	size=$(stat -c %s "$f" 2>/dev/null) || continue
+	i=0
+	while [ $((i * {{.}})) -lt "$size" ]; do
+		sum=$(dd if="$f" bs={{.}} skip=$i count=1 2>/dev/null | sha256sum) || break
+		echo "$i ${sum%% *} $f"
+		i=$((i + 1))
+	done
+done
+exit 0
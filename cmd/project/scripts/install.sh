@@ -0,0 +1,98 @@
+#!/bin/bash
+# Installs the dependencies for the development workflow on the Pod.
+# Rendered by renderScript from the project's agent.Config.
+set -euo pipefail
+IFS=$'\n\t'
+
+# Error handler to capture the failing command and its line number.
+error_handler() {
+	local exit_code=$?
+	local line_number=${BASH_LINENO[0]}
+	echo "Error: Command '${BASH_COMMAND}' exited with code ${exit_code} at line ${line_number}." >&2
+	exit "${exit_code}"
+}
+trap 'error_handler' ERR
+
+PACKAGE_MANAGER={{quote .PackageManager}}		# Specified in the project config
+PYTHON_VERSION={{quote .PythonVersion}}			# Specified in the project config
+PYTHON_VENV_PATH={{quote .VenvPath}}			# Path to the active Python virtual environment on the Pod
+ARCHIVED_VENV_PATH={{quote .ArchivedVenvPath}}	# Path to the archived Python virtual environment on the network volume
+REMOTE_PROJECT_PATH={{quote .ProjectDir}}		# Path to the remote project files, typically on the network volume
+REQUIREMENTS_PATH={{quote .RequirementsPath}}	# Path to the requirements file for the project, relative to the project root
+DEPENDENCIES=("wget" "sudo" "lsof" "git" "zstd"{{range .Packages}} {{quote .}}{{end}})
+
+
+function check_and_install_dependencies() {
+	apt-get update -qq
+	for dep in "${DEPENDENCIES[@]}"; do
+		if ! command -v "$dep" &> /dev/null; then
+			echo "[INFO] Installing $dep ..."
+			apt-get install -y "$dep"
+		fi
+	done
+
+	if ! command -v inotifywait &> /dev/null; then
+		echo "[INFO] Installing inotify-tools ..."
+		apt-get install -y inotify-tools
+	fi
+
+	if ! command -v uv &> /dev/null; then
+		echo "[INFO] Installing uv ..."
+		pip install uv
+	fi
+
+	# runpod CLI
+	wget -qO- cli.runpod.net | sudo bash &> /dev/null
+}
+
+function create_or_extract_venv() {
+	if [ ! -f "$PYTHON_VENV_PATH/bin/activate" ]; then
+		if [ -f "$ARCHIVED_VENV_PATH" ]; then
+			echo "[INFO] Extracting existing venv from archive: $ARCHIVED_VENV_PATH"
+			# The archive holds the contents of the venv directory, as the agent stores them.
+			mkdir -p "$PYTHON_VENV_PATH"
+			tar --use-compress-program="zstd -d --threads=0" -xf "$ARCHIVED_VENV_PATH" -C "$PYTHON_VENV_PATH"
+		else
+			echo "[INFO] Creating new venv with $PACKAGE_MANAGER..."
+			if [ "$PACKAGE_MANAGER" == "pip" ]; then
+				python"$PYTHON_VERSION" -m venv --upgrade-deps "$PYTHON_VENV_PATH"
+			elif [ "$PACKAGE_MANAGER" == "uv" ]; then
+				uv venv --python=python"$PYTHON_VERSION" "$PYTHON_VENV_PATH"
+			else
+				echo "[ERROR] Unsupported package manager: $PACKAGE_MANAGER"
+				exit 1
+			fi
+		fi
+	fi
+}
+
+function install_python_packages() {
+	if source "$PYTHON_VENV_PATH/bin/activate"; then
+		echo "[INFO] Activated venv at $PYTHON_VENV_PATH"
+		cd "$REMOTE_PROJECT_PATH"
+		echo "Current directory: $(pwd)"
+
+		# Install Python dependencies from requirements.txt
+		if [ "$PACKAGE_MANAGER" == "pip" ]; then
+			pip install -v --requirement "$REQUIREMENTS_PATH" --report /installreport.json hf_transfer
+		elif [ "$PACKAGE_MANAGER" == "uv" ]; then
+			uv pip install --requirement "$REQUIREMENTS_PATH" hf_transfer
+		else
+			echo "[ERROR] Unsupported package manager: $PACKAGE_MANAGER"
+		fi
+
+		echo "[INFO] Installed Python dependencies."
+	else
+		echo "[ERROR] Failed to activate venv."
+		exit 1
+	fi
+}
+
+# 1) Install apt & pip dependencies
+check_and_install_dependencies
+
+# 2) Create or extract virtual environment
+create_or_extract_venv
+
+# 3) Install Python packages
+install_python_packages
@@ -0,0 +1,105 @@
+package project
+
+import (
+	"cli/agent"
+	"flag"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/pelletier/go-toml"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// A project whose paths need quoting, to show that every value reaches the
+// shell as a single word.
+var scriptConfig = agent.Config{
+	ProjectDir:       "/runpod-volume/x/dev/my 'proj' $(id)",
+	VenvPath:         "/x/venv",
+	ArchivedVenvPath: "/runpod-volume/x/dev-venv.tar.zst",
+	RequirementsPath: "builder/requirements \"1\".txt",
+	HandlerPath:      "src/handler.py",
+	PackageManager:   "uv",
+	PythonVersion:    "3.10",
+	APIPort:          8080,
+	TriggerFile:      "/tmp/podflow/x/trigger",
+	Packages:         []string{"rsync"},
+}
+
+func TestScriptsGolden(t *testing.T) {
+	for _, name := range []string{"install.sh", "devServer.sh"} {
+		t.Run(name, func(t *testing.T) {
+			script, err := renderScript(name, scriptConfig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, []byte(script), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if script != string(want) {
+				t.Errorf("%s differs from %s; rerun with -update if the change is intended:\n%s", name, golden, script)
+			}
+
+			if _, err := exec.LookPath("bash"); err == nil {
+				if out, err := exec.Command("bash", "-n", "-c", script).CombinedOutput(); err != nil {
+					t.Errorf("bash -n: %v\n%s", err, out)
+				}
+			}
+		})
+	}
+}
+
+func TestValidateProjectConfig(t *testing.T) {
+	valid := `
+name = "my project"
+[project]
+uuid = "abc-123"
+volume_mount_path = "/runpod-volume"
+[runtime]
+handler_path = "src/handler.py"
+requirements_path = "builder/requirements.txt"
+python_version = "3.10"
+package_manager = "pip"
+`
+	tests := []struct {
+		name    string
+		replace [2]string
+		wantErr string
+	}{
+		{name: "valid"},
+		{name: "quote in name", replace: [2]string{`"my project"`, `"my 'project'"`}, wantErr: "name ="},
+		{name: "command substitution in path", replace: [2]string{`"/runpod-volume"`, `"/$(id)"`}, wantErr: "project.volume_mount_path"},
+		{name: "letters in python version", replace: [2]string{`"3.10"`, `"3.10; id"`}, wantErr: "runtime.python_version"},
+		{name: "unknown package manager", replace: [2]string{`"pip"`, `"conda"`}, wantErr: "runtime.package_manager"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc := valid
+			if tt.replace[0] != "" {
+				doc = strings.Replace(doc, tt.replace[0], tt.replace[1], 1)
+			}
+			config, err := toml.Load(doc)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = validateProjectConfig(config)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("unexpected error: %v", err)
+				}
+			} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("error %v, want one mentioning %q", err, tt.wantErr)
+			}
+		})
+	}
+}
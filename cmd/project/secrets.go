@@ -0,0 +1,214 @@
+package project
+
+import (
+	"bytes"
+	"cli/ignore"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Files that look like secrets are kept off the Pod unless a pattern in
+// allow_secrets in the [sync] table of runpod.toml matches them.
+var secretFilePatterns = []string{
+	".env",
+	".env.*",
+	"!.env.example",
+	"!.env.sample",
+	"!.env.template",
+	"*.pem",
+	"*.key",
+	"*.p12",
+	"*.pfx",
+	"*.keystore",
+	"id_rsa",
+	"id_dsa",
+	"id_ecdsa",
+	"id_ed25519",
+	".netrc",
+	".pgpass",
+	".pypirc",
+	".git-credentials",
+	"**/.aws/credentials",
+	"**/.docker/config.json",
+	"kubeconfig",
+	"*service-account*.json",
+	"application_default_credentials.json",
+}
+
+// secretContentPatterns are checked against the start of every file up to
+// secretScanLimit, with a description of what they find.
+var secretContentPatterns = []struct {
+	re   *regexp.Regexp
+	what string
+}{
+	{regexp.MustCompile(`-----BEGIN (?:[A-Z]+ )?PRIVATE KEY-----`), "a private key"},
+	{regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`), "an AWS access key"},
+	{regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}\b`), "a GitHub token"},
+	{regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`), "a Slack token"},
+	{regexp.MustCompile(`\bhf_[A-Za-z0-9]{30,}\b`), "a Hugging Face token"},
+	{regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9_-]{32,}\b`), "an API secret key"},
+}
+
+// secretScanLimit is how much of each file is searched for secretContentPatterns.
+const secretScanLimit = 1 << 20
+
+// secretSource names the rules added for secrets in 'podflow ignore check'.
+const secretSource = "secret scan (allow with [sync] allow_secrets)"
+
+type secretFile struct {
+	path   string // relative to the project root
+	reason string
+}
+
+var secretNames = func() *ignore.Matcher {
+	matcher, err := ignore.New(secretFilePatterns)
+	if err != nil {
+		panic(err)
+	}
+	return matcher
+}()
+
+// findSecrets returns the files among paths, relative to root, that look like
+// secrets and are not allowed by allow_secrets.
+func findSecrets(root string, paths []string) ([]secretFile, error) {
+	allowed, err := ignore.New(syncPatterns("allow_secrets"))
+	if err != nil {
+		return nil, fmt.Errorf("reading allow_secrets: %w", err)
+	}
+	var secrets []secretFile
+	for _, p := range paths {
+		if allowed.Match(p, false) {
+			continue
+		}
+		if secretNames.Match(p, false) {
+			secrets = append(secrets, secretFile{path: p, reason: "file name"})
+			continue
+		}
+		if what := scanForSecret(filepath.Join(root, filepath.FromSlash(p))); what != "" {
+			secrets = append(secrets, secretFile{path: p, reason: "contains " + what})
+		}
+	}
+	return secrets, nil
+}
+
+// secretScan is the result of scanning a file of the given size and mtime.
+type secretScan struct {
+	size    int64
+	modTime time.Time
+	what    string
+}
+
+// secretScans caches scanForSecret's results by path, so that the syncs of a
+// dev session only read the files that are new or have changed since.
+var (
+	secretScansMu sync.Mutex
+	secretScans   = map[string]secretScan{}
+)
+
+// scanForSecret describes the first secret found in file, or returns "".
+// Binary files are skipped.
+func scanForSecret(file string) string {
+	info, err := os.Stat(file)
+	if err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	secretScansMu.Lock()
+	cached, ok := secretScans[file]
+	secretScansMu.Unlock()
+	if ok && cached.size == info.Size() && cached.modTime.Equal(info.ModTime()) {
+		return cached.what
+	}
+
+	what, err := scanFile(file)
+	if err != nil {
+		return ""
+	}
+	secretScansMu.Lock()
+	secretScans[file] = secretScan{size: info.Size(), modTime: info.ModTime(), what: what}
+	secretScansMu.Unlock()
+	return what
+}
+
+func scanFile(file string) (string, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(io.LimitReader(f, secretScanLimit))
+	if err != nil {
+		return "", err
+	}
+	if bytes.IndexByte(content[:min(len(content), 8000)], 0) >= 0 {
+		return "", nil
+	}
+	for _, pattern := range secretContentPatterns {
+		if pattern.re.Match(content) {
+			return pattern.what, nil
+		}
+	}
+	return "", nil
+}
+
+// excludeSecrets returns matcher with rules added for the secrets among paths,
+// and those paths without them.
+func excludeSecrets(matcher *ignore.Matcher, root string, paths []string) (*ignore.Matcher, []string, error) {
+	secrets, err := findSecrets(root, paths)
+	if err != nil || len(secrets) == 0 {
+		return matcher, paths, err
+	}
+	reportSecrets(secrets)
+
+	blocked := map[string]bool{}
+	patterns := make([]string, 0, len(secrets))
+	for _, secret := range secrets {
+		blocked[secret.path] = true
+		patterns = append(patterns, ignore.Literal(secret.path))
+	}
+	matcher, err = matcher.With(secretSource, patterns)
+	if err != nil {
+		return nil, nil, err
+	}
+	kept := make([]string, 0, len(paths)-len(secrets))
+	for _, p := range paths {
+		if !blocked[p] {
+			kept = append(kept, p)
+		}
+	}
+	return matcher, kept, nil
+}
+
+var (
+	reportedSecretsMu sync.Mutex
+	reportedSecrets   = map[string]bool{}
+)
+
+// reportSecrets lists the secrets not already reported by this process.
+func reportSecrets(secrets []secretFile) {
+	reportedSecretsMu.Lock()
+	defer reportedSecretsMu.Unlock()
+
+	var fresh []secretFile
+	for _, secret := range secrets {
+		if !reportedSecrets[secret.path] {
+			reportedSecrets[secret.path] = true
+			fresh = append(fresh, secret)
+		}
+	}
+	if len(fresh) == 0 {
+		return
+	}
+	sort.Slice(fresh, func(i, j int) bool { return fresh[i].path < fresh[j].path })
+	fmt.Println("Not syncing files that look like secrets:")
+	for _, secret := range fresh {
+		fmt.Printf("  %s (%s)\n", secret.path, secret.reason)
+	}
+	fmt.Println("To sync them anyway, list them in allow_secrets in the [sync] table of runpod.toml.")
+}
@@ -0,0 +1,493 @@
+package project
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/pelletier/go-toml"
+)
+
+// A detached dev session ('podflow dev --detach') leaves the API server
+// running on the Pod and its file sync to a background copy of this program.
+// Each session is recorded in ~/.runpod/sessions/<project id>.json, which
+// also serves as the pidfile of the sync process, next to that process's log.
+// The sync process holds a lock on <project id>.lock for as long as it runs,
+// so that a PID reused by another process after it died is never taken for
+// it.
+
+type devSession struct {
+	ProjectID   string    `json:"project_id"`
+	ProjectName string    `json:"project_name"`
+	ProjectDir  string    `json:"project_dir"` // local project folder being synced
+	PodID       string    `json:"pod_id"`
+	PID         int       `json:"pid"` // of the sync process
+	OnExit      string    `json:"on_exit"`
+	Started     time.Time `json:"started"`
+}
+
+// syncStopTimeout is how long the sync process gets for its final pull.
+const syncStopTimeout = time.Minute
+
+// errLocked is returned by lockFile when another process holds the lock.
+var errLocked = errors.New("locked by another process")
+
+func sessionsDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".runpod", "sessions"), nil
+}
+
+func sessionFiles(projectID string) (sessionFile, logFile string, err error) {
+	dir, err := sessionsDir()
+	if err != nil {
+		return "", "", err
+	}
+	return filepath.Join(dir, projectID+".json"), filepath.Join(dir, projectID+".log"), nil
+}
+
+func sessionLockFile(projectID string) (string, error) {
+	dir, err := sessionsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, projectID+".lock"), nil
+}
+
+func loadSession(projectID string) (*devSession, error) {
+	sessionFile, _, err := sessionFiles(projectID)
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(sessionFile)
+	if err != nil {
+		return nil, err
+	}
+	var session devSession
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, fmt.Errorf("reading %s: %w", sessionFile, err)
+	}
+	return &session, nil
+}
+
+func (s *devSession) save() error {
+	sessionFile, _, err := sessionFiles(s.ProjectID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(sessionFile), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	// The sync process may be reading the file while it is saved again, so
+	// it is replaced rather than rewritten in place
+	tmp, err := os.CreateTemp(filepath.Dir(sessionFile), "."+s.ProjectID+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), sessionFile)
+}
+
+// remove forgets the session, keeping the log of its sync process for
+// reference. The lock file stays: a sync process that would not stop may
+// still hold a lock on it, which a new file in its place would not show.
+func (s *devSession) remove() error {
+	sessionFile, _, err := sessionFiles(s.ProjectID)
+	if err != nil {
+		return err
+	}
+	return os.Remove(sessionFile)
+}
+
+// lockSync marks the calling process as the session's running sync process
+// until the returned file is closed.
+func (s *devSession) lockSync() (*os.File, error) {
+	name, err := sessionLockFile(s.ProjectID)
+	if err != nil {
+		return nil, err
+	}
+	f, err := lockFile(name)
+	if errors.Is(err, errLocked) {
+		return nil, fmt.Errorf("file sync for project '%s' is already running", s.ProjectName)
+	}
+	return f, err
+}
+
+// syncRunning reports whether the session's sync process is running: a
+// process with its PID is not enough, it must also hold the session's lock.
+func (s *devSession) syncRunning() bool {
+	if s.PID <= 0 {
+		return false
+	}
+	name, err := sessionLockFile(s.ProjectID)
+	if err != nil {
+		return false
+	}
+	f, err := lockFile(name)
+	if err == nil {
+		f.Close()
+	}
+	return errors.Is(err, errLocked)
+}
+
+// stopSync ends the sync process, which pulls files one last time on the way
+// out where the platform lets it exit gracefully. It fails if the process is
+// still running afterwards, as nothing else may sync the project then.
+func (s *devSession) stopSync() error {
+	if !s.syncRunning() {
+		return nil
+	}
+	fmt.Printf("Stopping file sync for project '%s'...\n", s.ProjectName)
+	if err := terminateProcess(s.PID); err != nil && s.syncRunning() {
+		return fmt.Errorf("stopping file sync (pid %d): %w", s.PID, err)
+	}
+	for deadline := time.Now().Add(syncStopTimeout); time.Now().Before(deadline); {
+		if !s.syncRunning() {
+			return nil
+		}
+		time.Sleep(200 * time.Millisecond)
+	}
+	return fmt.Errorf("file sync (pid %d) is still running after %s; end it before trying again", s.PID, syncStopTimeout)
+}
+
+func listSessions() ([]*devSession, error) {
+	dir, err := sessionsDir()
+	if err != nil {
+		return nil, err
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	var sessions []*devSession
+	for _, file := range files {
+		session, err := loadSession(strings.TrimSuffix(filepath.Base(file), ".json"))
+		if err != nil {
+			fmt.Printf("Skipping %s: %v\n", file, err)
+			continue
+		}
+		sessions = append(sessions, session)
+	}
+	sort.Slice(sessions, func(i, j int) bool { return sessions[i].Started.Before(sessions[j].Started) })
+	return sessions, nil
+}
+
+// findSession returns the detached session for target, a project name or ID
+// or a Pod ID. Without a target it is the session of the project in the
+// current directory, or the only session there is.
+func findSession(target string) (*devSession, error) {
+	if target == "" {
+		if _, err := os.Stat("runpod.toml"); err == nil {
+			config := loadProjectConfig()
+			projectID := config.GetPath([]string{"project", "uuid"}).(string)
+			session, err := loadSession(projectID)
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("no detached session for the project in this directory; start one with 'podflow dev --detach'")
+			}
+			return session, err
+		}
+	}
+
+	sessions, err := listSessions()
+	if err != nil {
+		return nil, err
+	}
+	var matched []*devSession
+	for _, session := range sessions {
+		if target == "" || target == session.ProjectID || target == session.ProjectName || target == session.PodID {
+			matched = append(matched, session)
+		}
+	}
+	switch {
+	case len(matched) == 1:
+		return matched[0], nil
+	case len(matched) == 0 && target == "":
+		return nil, fmt.Errorf("no detached sessions; start one with 'podflow dev --detach'")
+	case len(matched) == 0:
+		return nil, fmt.Errorf("no detached session for %q; list them with 'podflow session ls'", target)
+	}
+	return nil, fmt.Errorf("%d detached sessions match; name the project or Pod, see 'podflow session ls'", len(matched))
+}
+
+// sessionConfig loads the runpod.toml of the session's project, making its
+// folder the current directory as the rest of the project commands expect.
+func sessionConfig(session *devSession) (*toml.Tree, error) {
+	if err := os.Chdir(session.ProjectDir); err != nil {
+		return nil, fmt.Errorf("project folder of session: %w", err)
+	}
+	return loadAndValidateConfig()
+}
+
+// detachSession hands file sync for the project in the current directory to
+// a background process and records the session. The API server is already
+// running on the Pod by itself.
+func detachSession(config *toml.Tree, podID, policy string, started time.Time) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	session := &devSession{
+		ProjectID:   config.GetPath([]string{"project", "uuid"}).(string),
+		ProjectName: config.GetPath([]string{"name"}).(string),
+		ProjectDir:  cwd,
+		PodID:       podID,
+		OnExit:      policy,
+		Started:     started,
+	}
+	_, logFile, err := sessionFiles(session.ProjectID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(logFile), 0700); err != nil {
+		return err
+	}
+	log, err := os.OpenFile(logFile, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	defer log.Close()
+
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	args := []string{"session", "sync"}
+	if syncEngine != "" {
+		args = append(args, "--sync-engine", syncEngine)
+	}
+	if syncBandwidthLimit > 0 {
+		args = append(args, "--bwlimit", strconv.Itoa(syncBandwidthLimit))
+	}
+	cmd := exec.Command(executable, args...)
+	cmd.Dir = cwd
+	cmd.Stdout, cmd.Stderr = log, log
+	detachProcess(cmd)
+
+	// The sync process finds its Pod in the session
+	if err := session.save(); err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		session.remove()
+		return fmt.Errorf("starting file sync: %w", err)
+	}
+	session.PID = cmd.Process.Pid
+	cmd.Process.Release()
+	if err := session.save(); err != nil {
+		return err
+	}
+
+	fmt.Printf("Session for project '%s' is running in the background on Pod %s.\n", session.ProjectName, podID)
+	fmt.Println("  podflow logs -f       follow the API server")
+	fmt.Println("  podflow attach        bring the session back to a terminal")
+	fmt.Printf("  podflow session stop  end the session (on exit: %s)\n", policy)
+	fmt.Printf("File sync logs to %s\n", logFile)
+	return nil
+}
+
+// runSessionSync is the background process of a detached session: it keeps
+// the Pod in sync with the project in the current directory until told to
+// stop, then pulls files one last time.
+func runSessionSync() error {
+	config, err := loadAndValidateConfig()
+	if err != nil {
+		return err
+	}
+	projectID := config.GetPath([]string{"project", "uuid"}).(string)
+	session, err := loadSession(projectID)
+	if err != nil {
+		return fmt.Errorf("loading session: %w", err)
+	}
+	lock, err := session.lockSync()
+	if err != nil {
+		return err
+	}
+	defer lock.Close()
+
+	sshConn, err := PodSSHConnection(session.PodID)
+	if err != nil {
+		return fmt.Errorf("failed to establish SSH: %w", err)
+	}
+	sshConn.StayConnected()
+	defer sshConn.Close()
+
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(sigs)
+
+	projectPath := path.Join(config.GetPath([]string{"project", "volume_mount_path"}).(string), projectID, "dev")
+	cwd, _ := os.Getwd()
+	fmt.Printf("%s Syncing %s to Pod %s\n", time.Now().Format(time.RFC3339), cwd, session.PodID)
+	go sshConn.SyncDir(cwd, projectPath, devServerTrigger(projectID))
+
+	pull := pullPatterns()
+	var stopPulling func()
+	if len(pull) > 0 {
+		stopPulling = sshConn.pullPeriodically(cwd, projectPath, pull)
+	}
+
+	<-sigs
+	fmt.Printf("%s Stopping file sync\n", time.Now().Format(time.RFC3339))
+	if stopPulling != nil {
+		stopPulling()
+		if n, err := sshConn.Pull(cwd, projectPath, pull, true); err != nil {
+			fmt.Printf("Error pulling files from Pod: %v\n", err)
+		} else {
+			fmt.Printf("Pulled %d files from Pod\n", n)
+		}
+	}
+	return nil
+}
+
+// attachSession brings a detached session back to the foreground, as if it
+// had been started by 'podflow dev' in this terminal.
+func attachSession(target string) error {
+	session, err := findSession(target)
+	if err != nil {
+		return err
+	}
+	config, err := sessionConfig(session)
+	if err != nil {
+		return err
+	}
+	if err := session.stopSync(); err != nil {
+		return err
+	}
+
+	sshConn, err := PodSSHConnection(session.PodID)
+	if err != nil {
+		return fmt.Errorf("failed to establish SSH: %w", err)
+	}
+	sshConn.StayConnected()
+	defer sshConn.Close()
+
+	// Catch up on changes made between the sync process stopping and now
+	projectPath := path.Join(config.GetPath([]string{"project", "volume_mount_path"}).(string), session.ProjectID, "dev")
+	if err := sshConn.Push(session.ProjectDir, projectPath, false); err != nil {
+		return fmt.Errorf("failed to sync files: %w", err)
+	}
+
+	fmt.Printf("Attached to session for project '%s' on Pod %s. Press Ctrl+C to end it (on exit: %s).\n", session.ProjectName, session.PodID, session.OnExit)
+	signals := catchSessionSignals()
+	defer signals.stop()
+	err = runSession(sshConn, config, session.PodID, session.OnExit, session.Started, signals.interrupted)
+	if removeErr := session.remove(); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// stopSession ends a detached session: its sync, the API server, and then
+// the Pod according to the session's on exit policy.
+func stopSession(target string) error {
+	session, err := findSession(target)
+	if err != nil {
+		return err
+	}
+	config, err := sessionConfig(session)
+	if err != nil {
+		return err
+	}
+	if err := session.stopSync(); err != nil {
+		return err
+	}
+
+	sshConn, err := PodSSHConnection(session.PodID)
+	if err != nil {
+		return fmt.Errorf("failed to establish SSH: %w", err)
+	}
+	defer sshConn.Close()
+	fmt.Println("Stopping API server on Pod:", session.PodID)
+	if err := sshConn.stopSupervised(devServerDir(session.ProjectID)); err != nil {
+		fmt.Printf("Error stopping API server: %v\n", err)
+	}
+
+	remoteProjectPath := path.Join(config.GetPath([]string{"project", "volume_mount_path"}).(string), session.ProjectID, "dev", session.ProjectName)
+	endSession(sshConn, config, session.PodID, remoteProjectPath, session.OnExit, session.Started)
+	return session.remove()
+}
+
+// showLogs prints the output of the dev API server of a session, or of the
+// project in the current directory, so far. With follow, it keeps printing
+// it until the server stops.
+func showLogs(target string, follow bool) error {
+	var projectID, podID string
+	if session, err := findSession(target); err == nil {
+		projectID, podID = session.ProjectID, session.PodID
+	} else if _, statErr := os.Stat("runpod.toml"); target == "" && statErr == nil {
+		// A session in the foreground of another terminal is not recorded
+		config := loadProjectConfig()
+		projectID = config.GetPath([]string{"project", "uuid"}).(string)
+		if podID, err = getProjectPod(projectID); podID == "" || podID == "ERROR" {
+			return fmt.Errorf("no Pod for the project in this directory; start one with 'podflow dev'")
+		}
+	} else {
+		return err
+	}
+	sshConn, err := PodSSHConnection(podID)
+	if err != nil {
+		return fmt.Errorf("failed to establish SSH: %w", err)
+	}
+	defer sshConn.Close()
+
+	dir := devServerDir(projectID)
+	if follow {
+		sshConn.StayConnected()
+		return sshConn.followSupervised(dir, sshConn.printAgentLine)
+	}
+	_, _, logFile := supervisorFiles(dir)
+	out, err := sshConn.output("cat " + shellQuote(logFile))
+	if err != nil {
+		return err
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(out, "\n"), "\n") {
+		sshConn.printAgentLine(line)
+	}
+	return nil
+}
+
+// printSessions lists the detached sessions.
+func printSessions() error {
+	sessions, err := listSessions()
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		fmt.Println("No detached sessions.")
+		return nil
+	}
+	fmt.Printf("%-20s %-10s %-16s %-20s %-10s %s\n", "PROJECT", "ID", "POD", "STARTED", "SYNC", "ON EXIT")
+	for _, session := range sessions {
+		sync := "stopped"
+		if session.syncRunning() {
+			sync = "running"
+		}
+		fmt.Printf("%-20s %-10s %-16s %-20s %-10s %s\n", session.ProjectName, session.ProjectID, session.PodID,
+			session.Started.Local().Format("2006-01-02 15:04:05"), sync, session.OnExit)
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package project
+
+import (
+	"os"
+	"os/exec"
+	"testing"
+	"time"
+)
+
+func setHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+}
+
+// TestHelperProcess stands in for a process that happens to reuse the PID
+// of a sync process that died.
+func TestHelperProcess(t *testing.T) {
+	if os.Getenv("PODFLOW_HELPER_PROCESS") != "1" {
+		return
+	}
+	time.Sleep(time.Minute)
+	os.Exit(0)
+}
+
+func TestSessionSyncRunning(t *testing.T) {
+	setHome(t)
+	session := &devSession{ProjectID: "abc", ProjectName: "demo", PodID: "pod1", Started: time.Now()}
+	if err := session.save(); err != nil {
+		t.Fatal(err)
+	}
+
+	// The sync process died and its PID went to another process
+	other := exec.Command(os.Args[0], "-test.run=^TestHelperProcess$")
+	other.Env = append(os.Environ(), "PODFLOW_HELPER_PROCESS=1")
+	if err := other.Start(); err != nil {
+		t.Fatal(err)
+	}
+	exited := make(chan error, 1)
+	go func() { exited <- other.Wait() }()
+	defer other.Process.Kill()
+
+	session.PID = other.Process.Pid
+	if session.syncRunning() {
+		t.Error("syncRunning for a process without the session's lock")
+	}
+	if err := session.stopSync(); err != nil {
+		t.Errorf("stopSync: %v", err)
+	}
+	select {
+	case err := <-exited:
+		t.Fatalf("stopSync signalled a process that is not the sync process: %v", err)
+	case <-time.After(200 * time.Millisecond):
+	}
+
+	// The sync process holds the lock while it runs
+	lock, err := session.lockSync()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !session.syncRunning() {
+		t.Error("syncRunning = false while the lock is held")
+	}
+	if _, err := session.lockSync(); err == nil {
+		t.Error("a second sync process took the lock")
+	}
+	// Forgetting the session must not hide a sync process that is still running
+	if err := session.remove(); err != nil {
+		t.Fatal(err)
+	}
+	if !session.syncRunning() {
+		t.Error("syncRunning = false after the session was removed while the lock is held")
+	}
+	lock.Close()
+	if session.syncRunning() {
+		t.Error("syncRunning = true after the lock was released")
+	}
+}
+
+func TestFindSession(t *testing.T) {
+	setHome(t)
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	for i, s := range []*devSession{
+		{ProjectID: "aaa", ProjectName: "first", PodID: "pod1", OnExit: "stop"},
+		{ProjectID: "bbb", ProjectName: "second", PodID: "pod2", OnExit: "keep"},
+	} {
+		s.Started = t0.Add(time.Duration(i) * time.Hour)
+		if err := s.save(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	sessions, err := listSessions()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(sessions) != 2 || sessions[0].ProjectID != "aaa" || sessions[1].OnExit != "keep" || !sessions[1].Started.Equal(t0.Add(time.Hour)) {
+		t.Errorf("listSessions = %+v, %+v", sessions[0], sessions[1])
+	}
+
+	tests := []struct {
+		target string
+		want   string // project ID, or "" for an error
+	}{
+		{"aaa", "aaa"},
+		{"second", "bbb"},
+		{"pod1", "aaa"},
+		{"nope", ""},
+	}
+	for _, tt := range tests {
+		session, err := findSession(tt.target)
+		got := ""
+		if err == nil {
+			got = session.ProjectID
+		}
+		if got != tt.want {
+			t.Errorf("findSession(%q) = %q, %v; want %q", tt.target, got, err, tt.want)
+		}
+	}
+
+	sessions[0].remove()
+	if session, err := findSession(""); err != nil || session.ProjectID != "bbb" {
+		t.Errorf("findSession(\"\") with one session = %v, %v; want bbb", session, err)
+	}
+}
@@ -0,0 +1,37 @@
+//go:build !windows
+
+package project
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+// detachProcess lets cmd outlive this process and its terminal.
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+}
+
+// lockFile opens name and takes an exclusive lock on it, held until the file
+// is closed or the process exits. It fails with errLocked while another open
+// file holds the lock.
+func lockFile(name string) (*os.File, error) {
+	f, err := os.OpenFile(name, os.O_CREATE|os.O_RDWR, 0600)
+	if err != nil {
+		return nil, err
+	}
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if err == syscall.EWOULDBLOCK {
+			return nil, errLocked
+		}
+		return nil, err
+	}
+	return f, nil
+}
+
+// terminateProcess asks the process to exit, which lets it clean up.
+func terminateProcess(pid int) error {
+	return syscall.Kill(pid, syscall.SIGTERM)
+}
@@ -0,0 +1,47 @@
+//go:build windows
+
+package project
+
+import (
+	"os"
+	"os/exec"
+	"syscall"
+)
+
+const (
+	detachedProcess       = 0x00000008 // DETACHED_PROCESS
+	errorSharingViolation = 32         // ERROR_SHARING_VIOLATION
+)
+
+// detachProcess lets cmd outlive this process and its console.
+func detachProcess(cmd *exec.Cmd) {
+	cmd.SysProcAttr = &syscall.SysProcAttr{CreationFlags: syscall.CREATE_NEW_PROCESS_GROUP | detachedProcess}
+}
+
+// lockFile opens name without sharing it, which keeps any other open of the
+// file failing until it is closed or the process exits. It fails with
+// errLocked while another open file holds it.
+func lockFile(name string) (*os.File, error) {
+	path, err := syscall.UTF16PtrFromString(name)
+	if err != nil {
+		return nil, err
+	}
+	handle, err := syscall.CreateFile(path, syscall.GENERIC_READ|syscall.GENERIC_WRITE, 0, nil,
+		syscall.OPEN_ALWAYS, syscall.FILE_ATTRIBUTE_NORMAL, 0)
+	if err == syscall.Errno(errorSharingViolation) {
+		return nil, errLocked
+	} else if err != nil {
+		return nil, err
+	}
+	return os.NewFile(uintptr(handle), name), nil
+}
+
+// terminateProcess ends the process. Windows cannot ask a detached process
+// to exit, so it gets no chance to clean up.
+func terminateProcess(pid int) error {
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	return process.Kill()
+}
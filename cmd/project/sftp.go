@@ -0,0 +1,292 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/pkg/sftp"
+	"github.com/schollz/progressbar/v3"
+)
+
+// partialSuffix marks a file that is still being transferred. An interrupted
+// copy leaves it behind so the next copy of the same file can resume. The
+// size and modification time of the source it was copied from are kept in a
+// file with sourceSuffix appended, so that a partial of a source that has
+// changed since is started over instead of resumed.
+const (
+	partialSuffix = ".podflow-part"
+	sourceSuffix  = ".src"
+)
+
+type CopyOptions struct {
+	Recursive bool
+	Quiet     bool // no progress bars
+}
+
+// fileSystem is the subset of file operations needed to copy between this
+// machine and a pod, so the same code handles uploads and downloads.
+type fileSystem interface {
+	Stat(name string) (fs.FileInfo, error)
+	ReadDir(name string) ([]fs.FileInfo, error)
+	Open(name string) (io.ReadSeekCloser, error)
+	// OpenAppend opens name for writing at its end, creating it if needed.
+	OpenAppend(name string) (io.WriteCloser, error)
+	Truncate(name string) error
+	Chmod(name string, mode fs.FileMode) error
+	Remove(name string) error
+	MkdirAll(name string) error
+	Rename(oldname, newname string) error
+	Join(elem ...string) string
+	Base(name string) string
+}
+
+type localFS struct{}
+
+func (localFS) Stat(name string) (fs.FileInfo, error) { return os.Stat(name) }
+
+func (localFS) ReadDir(name string) ([]fs.FileInfo, error) {
+	entries, err := os.ReadDir(name)
+	if err != nil {
+		return nil, err
+	}
+	infos := make([]fs.FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, info)
+	}
+	return infos, nil
+}
+
+func (localFS) Open(name string) (io.ReadSeekCloser, error) { return os.Open(name) }
+
+func (localFS) OpenAppend(name string) (io.WriteCloser, error) {
+	return os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+}
+
+func (localFS) Truncate(name string) error                { return os.Truncate(name, 0) }
+func (localFS) Chmod(name string, mode fs.FileMode) error { return os.Chmod(name, mode) }
+func (localFS) Remove(name string) error                  { return os.Remove(name) }
+func (localFS) MkdirAll(name string) error                { return os.MkdirAll(name, 0755) }
+func (localFS) Rename(oldname, newname string) error      { return os.Rename(oldname, newname) }
+func (localFS) Join(elem ...string) string                { return filepath.Join(elem...) }
+func (localFS) Base(name string) string                   { return filepath.Base(name) }
+
+type remoteFS struct {
+	client *sftp.Client
+}
+
+func (r remoteFS) Stat(name string) (fs.FileInfo, error)       { return r.client.Stat(name) }
+func (r remoteFS) ReadDir(name string) ([]fs.FileInfo, error)  { return r.client.ReadDir(name) }
+func (r remoteFS) Open(name string) (io.ReadSeekCloser, error) { return r.client.Open(name) }
+
+func (r remoteFS) OpenAppend(name string) (io.WriteCloser, error) {
+	f, err := r.client.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_APPEND)
+	if err != nil {
+		return nil, err
+	}
+	// Not every server honours O_APPEND, so position the handle explicitly.
+	if _, err := f.Seek(0, io.SeekEnd); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return f, nil
+}
+
+func (r remoteFS) Truncate(name string) error                { return r.client.Truncate(name, 0) }
+func (r remoteFS) Chmod(name string, mode fs.FileMode) error { return r.client.Chmod(name, mode) }
+func (r remoteFS) Remove(name string) error                  { return r.client.Remove(name) }
+func (r remoteFS) MkdirAll(name string) error                { return r.client.MkdirAll(name) }
+func (r remoteFS) Join(elem ...string) string                { return path.Join(elem...) }
+func (r remoteFS) Base(name string) string                   { return path.Base(name) }
+
+func (r remoteFS) Rename(oldname, newname string) error {
+	// Plain SFTP rename refuses to replace an existing file.
+	if err := r.client.PosixRename(oldname, newname); err == nil {
+		return nil
+	}
+	r.client.Remove(newname)
+	return r.client.Rename(oldname, newname)
+}
+
+// SFTP opens an SFTP session on the existing SSH connection.
+func (sshConn *SSHConnection) SFTP() (*sftp.Client, error) {
+	client, err := sftp.NewClient(sshConn.Client())
+	if err != nil {
+		return nil, fmt.Errorf("starting SFTP session: %w", err)
+	}
+	return client, nil
+}
+
+// Upload copies a local file or directory to remotePath on the pod.
+func (sshConn *SSHConnection) Upload(localPath string, remotePath string, opts CopyOptions) error {
+	client, err := sshConn.SFTP()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return copyPath(localFS{}, localPath, remoteFS{client}, remotePath, opts)
+}
+
+// Download copies a file or directory at remotePath on the pod to localPath.
+func (sshConn *SSHConnection) Download(remotePath string, localPath string, opts CopyOptions) error {
+	client, err := sshConn.SFTP()
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+	return copyPath(remoteFS{client}, remotePath, localFS{}, localPath, opts)
+}
+
+// copyPath follows cp semantics: copying into an existing directory places
+// the source inside it under its own name.
+func copyPath(srcFS fileSystem, src string, dstFS fileSystem, dst string, opts CopyOptions) error {
+	info, err := srcFS.Stat(src)
+	if err != nil {
+		return err
+	}
+	if dstInfo, err := dstFS.Stat(dst); err == nil && dstInfo.IsDir() {
+		dst = dstFS.Join(dst, srcFS.Base(src))
+	}
+	if info.IsDir() {
+		if !opts.Recursive {
+			return fmt.Errorf("%s is a directory (use -r to copy it)", src)
+		}
+		return copyDir(srcFS, src, dstFS, dst, opts)
+	}
+	return copyFile(srcFS, src, info, dstFS, dst, opts)
+}
+
+func copyDir(srcFS fileSystem, src string, dstFS fileSystem, dst string, opts CopyOptions) error {
+	if err := dstFS.MkdirAll(dst); err != nil {
+		return fmt.Errorf("creating directory %s: %w", dst, err)
+	}
+	entries, err := srcFS.ReadDir(src)
+	if err != nil {
+		return fmt.Errorf("reading directory %s: %w", src, err)
+	}
+	for _, entry := range entries {
+		srcPath, dstPath := srcFS.Join(src, entry.Name()), dstFS.Join(dst, entry.Name())
+		switch {
+		case entry.IsDir():
+			err = copyDir(srcFS, srcPath, dstFS, dstPath, opts)
+		case entry.Mode().IsRegular():
+			err = copyFile(srcFS, srcPath, entry, dstFS, dstPath, opts)
+		default:
+			// Symlinks, sockets and devices are not copied.
+			continue
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// copyFile writes src to a partial file next to dst and renames it into place
+// once complete, with the mode of src. A partial file left by an earlier
+// attempt is resumed if src has not changed since.
+func copyFile(srcFS fileSystem, src string, info fs.FileInfo, dstFS fileSystem, dst string, opts CopyOptions) error {
+	partial := dst + partialSuffix
+	offset, err := resumeOffset(dstFS, partial, info)
+	if err != nil {
+		return err
+	}
+
+	in, err := srcFS.Open(src)
+	if err != nil {
+		return fmt.Errorf("opening %s: %w", src, err)
+	}
+	defer in.Close()
+	if _, err := in.Seek(offset, io.SeekStart); err != nil {
+		return fmt.Errorf("seeking %s: %w", src, err)
+	}
+
+	out, err := dstFS.OpenAppend(partial)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", partial, err)
+	}
+
+	var bar *progressbar.ProgressBar
+	if opts.Quiet {
+		bar = progressbar.DefaultBytesSilent(info.Size())
+	} else {
+		bar = progressbar.DefaultBytes(info.Size(), srcFS.Base(src))
+	}
+	bar.Set64(offset)
+
+	_, err = io.Copy(io.MultiWriter(out, bar), in)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("copying %s: %w", src, err)
+	}
+	bar.Finish()
+
+	if err := dstFS.Rename(partial, dst); err != nil {
+		return fmt.Errorf("moving %s into place: %w", dst, err)
+	}
+	dstFS.Remove(partial + sourceSuffix)
+	if err := dstFS.Chmod(dst, info.Mode().Perm()); err != nil {
+		return fmt.Errorf("setting the mode of %s: %w", dst, err)
+	}
+	return nil
+}
+
+// resumeOffset returns how much of the source described by info the partial
+// file already holds, and records that source for the next attempt. A
+// partial of a different source, or of one that has changed since, is
+// emptied.
+func resumeOffset(dstFS fileSystem, partial string, info fs.FileInfo) (int64, error) {
+	stampFile := partial + sourceSuffix
+	stamp := fmt.Sprintf("%d %d\n", info.Size(), info.ModTime().Unix())
+
+	partInfo, err := dstFS.Stat(partial)
+	if errors.Is(err, fs.ErrNotExist) {
+		return 0, writeStamp(dstFS, stampFile, stamp)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("checking %s: %w", partial, err)
+	}
+	if partInfo.Size() <= info.Size() && readStamp(dstFS, stampFile) == stamp {
+		return partInfo.Size(), nil
+	}
+	if err := dstFS.Truncate(partial); err != nil {
+		return 0, fmt.Errorf("truncating %s: %w", partial, err)
+	}
+	return 0, writeStamp(dstFS, stampFile, stamp)
+}
+
+func readStamp(fsys fileSystem, name string) string {
+	f, err := fsys.Open(name)
+	if err != nil {
+		return ""
+	}
+	defer f.Close()
+	data, _ := io.ReadAll(io.LimitReader(f, 128))
+	return string(data)
+}
+
+func writeStamp(fsys fileSystem, name string, stamp string) error {
+	fsys.Remove(name)
+	f, err := fsys.OpenAppend(name)
+	if err != nil {
+		return fmt.Errorf("creating %s: %w", name, err)
+	}
+	_, err = io.WriteString(f, stamp)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", name, err)
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package project
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestCopyFileResume(t *testing.T) {
+	mtime := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name    string
+		partial string // left by an earlier attempt, if not ""
+		stamp   string // source recorded for the partial
+		want    string
+	}{
+		{name: "fresh copy", want: "hello world"},
+		// Upper case shows which bytes came from the partial
+		{name: "resumes partial of the same source", partial: "HELLO", stamp: "11 1714564800\n", want: "HELLO world"},
+		{name: "restarts partial of another size", partial: "HELLO", stamp: "12 1714564800\n", want: "hello world"},
+		{name: "restarts partial of another mtime", partial: "HELLO", stamp: "11 1714564801\n", want: "hello world"},
+		{name: "restarts partial without a source", partial: "HELLO", want: "hello world"},
+		{name: "restarts partial longer than the source", partial: "HELLO WORLD!", stamp: "11 1714564800\n", want: "hello world"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			src, dst := filepath.Join(dir, "src.sh"), filepath.Join(dir, "dst.sh")
+			if err := os.WriteFile(src, []byte("hello world"), 0750); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Chtimes(src, mtime, mtime); err != nil {
+				t.Fatal(err)
+			}
+			if tt.partial != "" {
+				os.WriteFile(dst+partialSuffix, []byte(tt.partial), 0644)
+			}
+			if tt.stamp != "" {
+				os.WriteFile(dst+partialSuffix+sourceSuffix, []byte(tt.stamp), 0644)
+			}
+
+			info, err := os.Stat(src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := copyFile(localFS{}, src, info, localFS{}, dst, CopyOptions{Quiet: true}); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(data) != tt.want {
+				t.Errorf("copied %q, want %q", data, tt.want)
+			}
+			dstInfo, err := os.Stat(dst)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if dstInfo.Mode().Perm() != 0750 {
+				t.Errorf("mode %v, want %v", dstInfo.Mode().Perm(), os.FileMode(0750))
+			}
+			for _, leftover := range []string{dst + partialSuffix, dst + partialSuffix + sourceSuffix} {
+				if _, err := os.Stat(leftover); err == nil {
+					t.Errorf("%s left behind", filepath.Base(leftover))
+				}
+			}
+		})
+	}
+}
+
+func TestCopyPathIntoDirectory(t *testing.T) {
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	os.MkdirAll(filepath.Join(src, "sub"), 0755)
+	os.WriteFile(filepath.Join(src, "a.txt"), []byte("a"), 0644)
+	os.WriteFile(filepath.Join(src, "sub", "b.txt"), []byte("b"), 0600)
+	dst := filepath.Join(dir, "dst")
+	os.Mkdir(dst, 0755)
+
+	if err := copyPath(localFS{}, src, localFS{}, dst, CopyOptions{Quiet: true}); err == nil {
+		t.Error("copying a directory without Recursive: expected an error")
+	}
+	if err := copyPath(localFS{}, src, localFS{}, dst, CopyOptions{Recursive: true, Quiet: true}); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{"src/a.txt": "a", "src/sub/b.txt": "b"} {
+		data, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(name)))
+		if err != nil || string(data) != want {
+			t.Errorf("%s = %q, %v; want %q", name, data, err, want)
+		}
+	}
+}
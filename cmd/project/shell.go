@@ -0,0 +1,89 @@
+package project
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/agent"
+	"golang.org/x/term"
+)
+
+// OpenShell connects to a pod and runs an interactive shell until it exits.
+func OpenShell(podId string, forwardAgent bool) error {
+	sshConn, err := PodSSHConnection(podId)
+	if err != nil {
+		return err
+	}
+	defer sshConn.Close()
+	return sshConn.Shell(forwardAgent)
+}
+
+// Shell runs an interactive login shell on the pod, attached to the local terminal.
+func (sshConn *SSHConnection) Shell(forwardAgent bool) error {
+	session, err := sshConn.Client().NewSession()
+	if err != nil {
+		return fmt.Errorf("failed to create SSH session: %w", err)
+	}
+	defer session.Close()
+
+	if forwardAgent {
+		socket := os.Getenv("SSH_AUTH_SOCK")
+		if socket == "" {
+			return errors.New("agent forwarding requested but SSH_AUTH_SOCK is not set")
+		}
+		if err := agent.ForwardToRemote(sshConn.Client(), socket); err != nil {
+			return fmt.Errorf("forwarding SSH agent: %w", err)
+		}
+		if err := agent.RequestAgentForwarding(session); err != nil {
+			return fmt.Errorf("requesting agent forwarding: %w", err)
+		}
+	}
+
+	session.Stdin = os.Stdin
+	session.Stdout = os.Stdout
+	session.Stderr = os.Stderr
+
+	// Without a terminal (e.g. piped input) run the shell without a PTY.
+	stdinFd := int(os.Stdin.Fd())
+	if term.IsTerminal(stdinFd) {
+		width, height, err := term.GetSize(int(os.Stdout.Fd()))
+		if err != nil {
+			width, height = 80, 24
+		}
+		termType := os.Getenv("TERM")
+		if termType == "" {
+			termType = "xterm-256color"
+		}
+		modes := ssh.TerminalModes{
+			ssh.ECHO:          1,
+			ssh.TTY_OP_ISPEED: 14400,
+			ssh.TTY_OP_OSPEED: 14400,
+		}
+		if err := session.RequestPty(termType, height, width, modes); err != nil {
+			return fmt.Errorf("requesting PTY: %w", err)
+		}
+
+		state, err := term.MakeRaw(stdinFd)
+		if err != nil {
+			return fmt.Errorf("setting terminal to raw mode: %w", err)
+		}
+		defer term.Restore(stdinFd, state)
+
+		stop := watchWindowSize(int(os.Stdout.Fd()), session)
+		defer stop()
+	}
+
+	if err := session.Start(withPodEnvironment("exec bash -l")); err != nil {
+		return fmt.Errorf("starting remote shell: %w", err)
+	}
+	err = session.Wait()
+
+	// The exit status of the last command typed into the shell is not an error of ours.
+	var exitErr *ssh.ExitError
+	if errors.As(err, &exitErr) {
+		return nil
+	}
+	return err
+}
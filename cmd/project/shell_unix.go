@@ -0,0 +1,37 @@
+//go:build !windows
+
+package project
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/term"
+)
+
+// watchWindowSize propagates local terminal resizes (SIGWINCH) to the remote PTY.
+func watchWindowSize(fd int, session *ssh.Session) (stop func()) {
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, syscall.SIGWINCH)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			case <-sigs:
+				if width, height, err := term.GetSize(fd); err == nil {
+					session.WindowChange(height, width)
+				}
+			}
+		}
+	}()
+
+	return func() {
+		signal.Stop(sigs)
+		close(done)
+	}
+}
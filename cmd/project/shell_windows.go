@@ -0,0 +1,37 @@
+//go:build windows
+
+package project
+
+import (
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/term"
+)
+
+// watchWindowSize propagates local terminal resizes to the remote PTY. Windows
+// has no SIGWINCH, so the console size is polled instead.
+func watchWindowSize(fd int, session *ssh.Session) (stop func()) {
+	done := make(chan struct{})
+
+	go func() {
+		lastWidth, lastHeight, _ := term.GetSize(fd)
+		ticker := time.NewTicker(500 * time.Millisecond)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				width, height, err := term.GetSize(fd)
+				if err != nil || (width == lastWidth && height == lastHeight) {
+					continue
+				}
+				lastWidth, lastHeight = width, height
+				session.WindowChange(height, width)
+			}
+		}
+	}()
+
+	return func() { close(done) }
+}
@@ -4,13 +4,18 @@ import (
 	"bufio"
 	"bytes"
 	"cli/api"
-	"errors"
+	"cli/ignore"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"os/exec"
+	"path"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/fatih/color"
@@ -22,10 +27,22 @@ const (
 	maxPollTime  = 5 * time.Minute // Adjusted for clarity
 )
 
-func getPodSSHInfo(podID string) (string, int, error) {
+// SSH proxy gateway used for pods without a public TCP port for SSH. It
+// routes on the user name, which is the pod's host ID.
+const (
+	sshProxyHost = "ssh.runpod.io"
+	sshProxyPort = 22
+	// sshProxyIdentity stands in for the pod ID in known_hosts, as every
+	// proxied pod presents the gateway's host key.
+	sshProxyIdentity = "runpod-proxy"
+)
+
+// getPodSSHInfo returns the user, host and port to SSH into the pod, falling
+// back to the proxy gateway when no public port is mapped to port 22.
+func getPodSSHInfo(podID string) (string, string, int, error) {
 	pods, err := api.GetPods()
 	if err != nil {
-		return "", 0, fmt.Errorf("getting pods: %w", err)
+		return "", "", 0, fmt.Errorf("getting pods: %w", err)
 	}
 
 	for _, pod := range pods {
@@ -34,168 +51,470 @@ func getPodSSHInfo(podID string) (string, int, error) {
 		}
 
 		if pod.DesiredStatus != "RUNNING" {
-			return "", 0, fmt.Errorf("pod desired status not RUNNING")
+			return "", "", 0, fmt.Errorf("pod desired status not RUNNING")
 		}
 		if pod.Runtime == nil {
-			return "", 0, fmt.Errorf("pod runtime is missing")
-		}
-		if pod.Runtime.Ports == nil {
-			return "", 0, fmt.Errorf("pod runtime ports are missing")
+			return "", "", 0, fmt.Errorf("pod runtime is missing")
 		}
 		for _, port := range pod.Runtime.Ports {
-			if port.PrivatePort == 22 {
-				return port.Ip, port.PublicPort, nil
+			if port.PrivatePort == 22 && port.IsIpPublic {
+				return "root", port.Ip, port.PublicPort, nil
 			}
 		}
-
+		if pod.Machine != nil && pod.Machine.PodHostId != "" {
+			return pod.Machine.PodHostId, sshProxyHost, sshProxyPort, nil
+		}
 	}
-	return "", 0, fmt.Errorf("no SSH port exposed on pod %s", podID)
+	return "", "", 0, fmt.Errorf("no SSH port exposed on pod %s", podID)
 }
 
 type SSHConnection struct {
 	podId      string
-	podIp      string
-	podPort    int
-	client     *ssh.Client
-	sshKeyPath string
+	auth       *sshAuth
+	sshKeyPath string // identity file the pod accepted, "" when it was an agent key
+
+	// Guarded by mu, as StayConnected replaces them when reconnecting.
+	mu          sync.Mutex
+	user        string
+	podIp       string
+	podPort     int
+	client      *ssh.Client
+	connected   bool
+	closed      bool
+	onReconnect []func()
+
+	agentOnce sync.Once
+	agentPath string // dev agent installed on the pod, "" to use the scripts
+}
+
+// Client returns the current SSH client, which changes after a reconnect.
+func (sshConn *SSHConnection) Client() *ssh.Client {
+	sshConn.mu.Lock()
+	defer sshConn.mu.Unlock()
+	return sshConn.client
+}
+
+// Connected reports whether the connection is up. It is only ever false while
+// StayConnected is trying to reconnect.
+func (sshConn *SSHConnection) Connected() bool {
+	sshConn.mu.Lock()
+	defer sshConn.mu.Unlock()
+	return sshConn.connected
+}
+
+// OnReconnect registers f to run after StayConnected has replaced a lost connection.
+func (sshConn *SSHConnection) OnReconnect(f func()) {
+	sshConn.mu.Lock()
+	defer sshConn.mu.Unlock()
+	sshConn.onReconnect = append(sshConn.onReconnect, f)
+}
+
+// Close closes the underlying SSH connection and stops reconnecting.
+func (sshConn *SSHConnection) Close() error {
+	sshConn.mu.Lock()
+	sshConn.closed = true
+	client := sshConn.client
+	sshConn.mu.Unlock()
+
+	sshConn.auth.close()
+	return client.Close()
+}
+
+const (
+	keepAliveInterval = 15 * time.Second
+	keepAliveTimeout  = 10 * time.Second
+	maxReconnectDelay = 30 * time.Second
+)
+
+// StayConnected sends keepalives to detect a dead connection and replaces it
+// with a new one, retrying with backoff, until Close is called.
+func (sshConn *SSHConnection) StayConnected() {
+	go sshConn.keepAlive()
+	go sshConn.reconnectLoop()
+}
+
+func (sshConn *SSHConnection) keepAlive() {
+	ticker := time.NewTicker(keepAliveInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		sshConn.mu.Lock()
+		client, closed := sshConn.client, sshConn.closed
+		sshConn.mu.Unlock()
+		if closed {
+			return
+		}
+
+		reply := make(chan error, 1)
+		go func() {
+			_, _, err := client.SendRequest("keepalive@openssh.com", true, nil)
+			reply <- err
+		}()
+		select {
+		case err := <-reply:
+			if err == nil {
+				continue
+			}
+		case <-time.After(keepAliveTimeout):
+		}
+		// Closing the client wakes up reconnectLoop.
+		client.Close()
+	}
+}
+
+func (sshConn *SSHConnection) reconnectLoop() {
+	for {
+		sshConn.Client().Wait()
+
+		sshConn.mu.Lock()
+		if sshConn.closed {
+			sshConn.mu.Unlock()
+			return
+		}
+		sshConn.connected = false
+		sshConn.mu.Unlock()
+		fmt.Printf("Lost connection to Pod %s, reconnecting...\n", sshConn.podId)
+
+		delay := time.Second
+		for {
+			user, podIp, podPort, err := getPodSSHInfo(sshConn.podId)
+			var client *ssh.Client
+			if err == nil {
+				client, err = dialPod(sshConn.podId, sshConn.auth, user, podIp, podPort)
+			}
+			if err == nil {
+				sshConn.mu.Lock()
+				if sshConn.closed {
+					sshConn.mu.Unlock()
+					client.Close()
+					return
+				}
+				sshConn.user, sshConn.podIp, sshConn.podPort = user, podIp, podPort
+				sshConn.client, sshConn.connected = client, true
+				callbacks := append([]func(){}, sshConn.onReconnect...)
+				sshConn.mu.Unlock()
+
+				fmt.Printf("Reconnected to Pod %s\n", sshConn.podId)
+				for _, f := range callbacks {
+					f()
+				}
+				break
+			}
+
+			fmt.Printf("Reconnect failed: %v; retrying in %s\n", err, delay)
+			time.Sleep(delay)
+			if delay *= 2; delay > maxReconnectDelay {
+				delay = maxReconnectDelay
+			}
+		}
+	}
+}
+
+// address returns the user, host and port the connection currently uses.
+func (sshConn *SSHConnection) address() (string, string, int) {
+	sshConn.mu.Lock()
+	defer sshConn.mu.Unlock()
+	return sshConn.user, sshConn.podIp, sshConn.podPort
 }
 
 func (sshConn *SSHConnection) getSshOptions() []string {
-	return []string{
-		"-o", "StrictHostKeyChecking=no",
+	// PodSSHConnection has already recorded the pod's host key by now.
+	knownHosts, _ := knownHostsPath()
+	_, _, podPort := sshConn.address()
+	options := []string{
+		"-o", "StrictHostKeyChecking=yes",
+		"-o", "UserKnownHostsFile=" + knownHosts,
 		"-o", "LogLevel=ERROR",
-		"-p", fmt.Sprint(sshConn.podPort),
-		"-i", sshConn.sshKeyPath,
+		"-p", fmt.Sprint(podPort),
+	}
+	// Without -i, ssh uses the agent, which is where the accepted key came from.
+	if sshConn.sshKeyPath != "" {
+		options = append(options, "-i", sshConn.sshKeyPath)
 	}
+	return options
 }
 
 func (sshConn *SSHConnection) Rsync(localDir string, remoteDir string, quiet bool) error {
-	rsyncCmdArgs := []string{"--compress", "--archive", "--verbose", "--no-owner", "--no-group"}
+	// Every transferred file is printed with its size, which drives the progress bar
+	rsyncCmdArgs := []string{"--compress", "--archive", "--no-owner", "--no-group", "--out-format=%l %n"}
+	if limit := bandwidthLimit(); limit > 0 {
+		rsyncCmdArgs = append(rsyncCmdArgs, fmt.Sprintf("--bwlimit=%d", limit))
+	}
 
-	// Retrieve and apply ignore patterns
-	patterns, err := GetIgnoreList()
+	// Send exactly the files the ignore rules keep, so rsync never applies
+	// its own, slightly different, pattern syntax.
+	matcher, err := loadSyncMatcher(localDir)
 	if err != nil {
 		return fmt.Errorf("getting ignore list: %w", err)
 	}
-	for _, pat := range patterns {
-		rsyncCmdArgs = append(rsyncCmdArgs, "--exclude", pat)
+	var files []string
+	err = matcher.Walk(localDir, func(relPath string, d fs.DirEntry) error {
+		if !d.IsDir() {
+			files = append(files, relPath)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("listing files to sync: %w", err)
 	}
-
-	//Filter from .runpodignore
-	rsyncCmdArgs = append(rsyncCmdArgs, "--filter=:- .runpodignore")
+	fileList := strings.Join(files, "\n") + "\n"
+	rsyncCmdArgs = append(rsyncCmdArgs, "--files-from=-")
 
 	// Prepare SSH options for rsync
 	sshOptions := fmt.Sprintf("ssh %s", strings.Join(sshConn.getSshOptions(), " "))
-	rsyncCmdArgs = append(rsyncCmdArgs, "-e", sshOptions, localDir, fmt.Sprintf("root@%s:%s", sshConn.podIp, remoteDir))
+	user, podIp, _ := sshConn.address()
+	remoteRoot := path.Join(remoteDir, filepath.Base(localDir))
+	rsyncCmdArgs = append(rsyncCmdArgs, "-e", sshOptions, localDir+string(filepath.Separator), fmt.Sprintf("%s@%s:%s/", user, podIp, remoteRoot))
 
-	// Perform a dry run to check if files need syncing
+	// Perform a dry run to find the files that need syncing
 	dryRunArgs := append(rsyncCmdArgs, "--dry-run")
 	dryRunCmd := exec.Command("rsync", dryRunArgs...)
-	var dryRunBuf bytes.Buffer
-	dryRunCmd.Stdout = &dryRunBuf
-	dryRunCmd.Stderr = &dryRunBuf
+	dryRunCmd.Stdin = strings.NewReader(fileList)
+	var dryRunOut, dryRunErr bytes.Buffer
+	dryRunCmd.Stdout = &dryRunOut
+	dryRunCmd.Stderr = &dryRunErr
 	if err := dryRunCmd.Run(); err != nil {
-		return fmt.Errorf("running rsync dry run: %w", err)
+		return fmt.Errorf("running rsync dry run: %w: %s", err, strings.TrimSpace(dryRunErr.String()))
 	}
-	dryRunOutput := dryRunBuf.String()
 
-	// Parse the dry run output to determine if files need syncing
-	filesNeedSyncing := false
-	scanner := bufio.NewScanner(strings.NewReader(dryRunOutput))
+	pending := map[string]int64{}
+	var total int64
+	scanner := bufio.NewScanner(&dryRunOut)
 	for scanner.Scan() {
-		line := scanner.Text()
-
-		if line == "" || strings.Contains(line, "sending incremental file list") || strings.Contains(line, "total size is") || strings.Contains(line, "bytes/sec") || strings.Contains(line, "building file list") {
-			continue
-		}
-
-		filename := filepath.Base(line)
-		if filename == "" || filename == "." || strings.HasSuffix(line, "/") {
-			continue
+		if name, size, ok := parseRsyncFile(scanner.Text()); ok {
+			pending[name] = size
+			total += size
 		}
-
-		filesNeedSyncing = true
-		break
 	}
 	if err := scanner.Err(); err != nil {
 		return fmt.Errorf("scanning dry run output: %w", err)
 	}
-
-	// Add quiet flag if requested
-	if quiet {
-		rsyncCmdArgs = append(rsyncCmdArgs, "--quiet")
+	if len(pending) == 0 {
+		return nil
 	}
+	warnLargeFiles(pending)
 
-	if filesNeedSyncing {
-		fmt.Println("Syncing files...")
-
-		cmd := exec.Command("rsync", rsyncCmdArgs...)
-		cmd.Stdout = os.Stdout
-		cmd.Stderr = os.Stderr
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("executing rsync command: %w", err)
+	meter := newSyncMeter(len(pending), total, quiet)
+	cmd := exec.Command("rsync", rsyncCmdArgs...)
+	cmd.Stdin = strings.NewReader(fileList)
+	cmd.Stderr = os.Stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return fmt.Errorf("failed to get stdout pipe: %w", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("executing rsync command: %w", err)
+	}
+	scanner = bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		if _, size, ok := parseRsyncFile(scanner.Text()); ok {
+			meter.progress(size)
 		}
 	}
+	if err := cmd.Wait(); err != nil {
+		return fmt.Errorf("executing rsync command: %w", err)
+	}
+	meter.finish()
 
 	return nil
 }
 
-// hasChanges checks if there are any modified files in localDir since lastSyncTime.
-func hasChanges(localDir string, lastSyncTime time.Time) (bool, string) {
-	var firstModifiedFile string = ""
+// parseRsyncFile parses a line printed with --out-format="%l %n", which is
+// not a file if the name ends in a slash.
+func parseRsyncFile(line string) (string, int64, bool) {
+	sizeText, name, found := strings.Cut(line, " ")
+	if !found || name == "" || strings.HasSuffix(name, "/") {
+		return "", 0, false
+	}
+	size, err := strconv.ParseInt(sizeText, 10, 64)
+	if err != nil {
+		return "", 0, false
+	}
+	return name, size, true
+}
 
-	err := filepath.Walk(localDir, func(path string, info os.FileInfo, err error) error {
-		if err != nil {
-			if os.IsNotExist(err) {
-				// Handle the case where a file has been removed
-				fmt.Printf("Detected a removed file at: %s\n", path)
-				return errors.New("change detected") // Stop walking
-			}
-			return err
+// maxIncrementalPaths is the batch size above which SyncDir falls back to syncing the whole tree.
+const maxIncrementalPaths = 500
+
+// RsyncPaths syncs only the given paths, relative to localDir, to the copy of
+// localDir under remoteDir that Rsync creates. Paths that no longer exist
+// locally are deleted on the pod.
+func (sshConn *SSHConnection) RsyncPaths(localDir string, remoteDir string, paths []string) error {
+	remoteRoot := path.Join(remoteDir, filepath.Base(localDir))
+
+	var existing, removed []string
+	for _, p := range paths {
+		if _, err := os.Lstat(filepath.Join(localDir, filepath.FromSlash(p))); err == nil {
+			existing = append(existing, p)
+		} else if os.IsNotExist(err) {
+			removed = append(removed, shellQuote(path.Join(remoteRoot, p)))
 		}
+	}
 
-		// Check if the file was modified after the last sync time
-		if info.ModTime().After(lastSyncTime) {
-			firstModifiedFile = path
-			return filepath.SkipDir // Skip the rest of the directory if a change is found
+	if len(removed) > 0 {
+		if err := sshConn.RunCommand("rm -rf -- " + strings.Join(removed, " ")); err != nil {
+			return fmt.Errorf("removing deleted files: %w", err)
 		}
-
+	}
+	if len(existing) == 0 {
 		return nil
-	})
+	}
 
-	if err != nil {
-		fmt.Printf("Error walking through directory: %v\n", err)
-		return false, ""
+	sshOptions := fmt.Sprintf("ssh %s", strings.Join(sshConn.getSshOptions(), " "))
+	user, podIp, _ := sshConn.address()
+	args := []string{"--compress", "--archive", "--no-owner", "--no-group", "--quiet", "--files-from=-"}
+	if limit := bandwidthLimit(); limit > 0 {
+		args = append(args, fmt.Sprintf("--bwlimit=%d", limit))
+	}
+	sizes := map[string]int64{}
+	for _, p := range existing {
+		if info, err := os.Stat(filepath.Join(localDir, filepath.FromSlash(p))); err == nil && !info.IsDir() {
+			sizes[p] = info.Size()
+		}
 	}
+	warnLargeFiles(sizes)
+	args = append(args, "-e", sshOptions, localDir+string(filepath.Separator), fmt.Sprintf("%s@%s:%s/", user, podIp, remoteRoot))
+	cmd := exec.Command("rsync", args...)
+	cmd.Stdin = strings.NewReader(strings.Join(existing, "\n") + "\n")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("executing rsync command: %w", err)
+	}
+	return nil
+}
 
-	return firstModifiedFile != "", firstModifiedFile
+// notifySynced overwrites notifyFile with the synced paths, one per line,
+// where "." stands for a full sync.
+func (sshConn *SSHConnection) notifySynced(notifyFile string, paths []string) {
+	if notifyFile == "" {
+		return
+	}
+	quoted := make([]string, len(paths))
+	for i, p := range paths {
+		quoted[i] = shellQuote(p)
+	}
+	command := fmt.Sprintf("mkdir -p %s && printf '%%s\\n' %s > %s",
+		shellQuote(path.Dir(notifyFile)), strings.Join(quoted, " "), shellQuote(notifyFile))
+	if err := sshConn.RunCommand(command); err != nil {
+		fmt.Printf("Error notifying Pod of synced files: %v\n", err)
+	}
+}
+
+// shellQuote quotes s for use as a single word in a POSIX shell command.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
 }
 
-func (sshConn *SSHConnection) SyncDir(localDir string, remoteDir string) {
-	syncFiles := func() {
-		// fmt.Println("Syncing files...")
-		err := sshConn.Rsync(localDir, remoteDir, true)
+// ShellCommand quotes each of args so that the remote shell runs the command
+// with exactly these arguments, spaces and quotes included.
+func ShellCommand(args []string) string {
+	quoted := make([]string, len(args))
+	for i, arg := range args {
+		quoted[i] = shellQuote(arg)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// SyncDir keeps remoteDir up to date with changes under localDir until the
+// process exits. Changes are picked up from file system events and only the
+// changed paths are sent. After each sync the paths are written to notifyFile
+// on the pod, if set, so that processes there can react to them.
+func (sshConn *SSHConnection) SyncDir(localDir string, remoteDir string, notifyFile string) {
+	syncAll := func() error {
+		err := sshConn.Push(localDir, remoteDir, true)
 		if err != nil {
 			fmt.Printf(" error: %v\n", err)
-			return
 		}
+		return err
 	}
 
-	// Start listening for events in a separate goroutine.
+	// The rules are reloaded whenever a .runpodignore file changes.
+	var matcher atomic.Pointer[ignore.Matcher]
+	loadMatcher := func() error {
+		m, err := loadSyncMatcher(localDir)
+		if err == nil {
+			matcher.Store(m)
+		}
+		return err
+	}
+	if err := loadMatcher(); err != nil {
+		fmt.Printf("Error reading ignore list, file sync disabled: %v\n", err)
+		return
+	}
+	ignored := func(relPath string, isDir bool) bool {
+		return matcher.Load().Match(relPath, isDir)
+	}
+
+	batches := make(chan []string)
 	go func() {
-		lastSyncTime := time.Now()
-		for {
-			time.Sleep(100 * time.Millisecond)
-			hasChanged, firstModifiedFile := hasChanges(localDir, lastSyncTime)
-			if hasChanged {
-				fmt.Printf("Local changes detected in %s\n", firstModifiedFile)
-				syncFiles()
-				lastSyncTime = time.Now()
-			}
+		if err := watchTree(localDir, ignored, batches); err != nil {
+			fmt.Printf("File watcher stopped, file sync disabled: %v\n", err)
 		}
 	}()
 
-	<-make(chan struct{})
+	// Changes made while disconnected are held back, then everything is synced
+	// once the connection is back.
+	reconnected := make(chan struct{}, 1)
+	sshConn.OnReconnect(func() {
+		select {
+		case reconnected <- struct{}{}:
+		default:
+		}
+	})
+	missed := false
+
+	for {
+		select {
+		case paths := <-batches:
+			for _, p := range paths {
+				if path.Base(p) == ignoreFileName {
+					if err := loadMatcher(); err != nil {
+						fmt.Printf("Error reading ignore list, keeping previous rules: %v\n", err)
+					}
+					// Files the new rules let through have not been sent yet.
+					paths = append(paths, ".")
+					break
+				}
+			}
+			// New or changed files may be secrets the last scan did not see
+			m, kept, err := excludeSecrets(matcher.Load(), localDir, paths)
+			if err != nil {
+				fmt.Printf("Error scanning for secrets, changes not synced: %v\n", err)
+				continue
+			}
+			matcher.Store(m)
+			if paths = kept; len(paths) == 0 {
+				continue
+			}
+			if !sshConn.Connected() {
+				missed = true
+				continue
+			}
+			if len(paths) == 1 {
+				fmt.Printf("Local changes detected in %s\n", paths[0])
+			} else {
+				fmt.Printf("Local changes detected in %d files\n", len(paths))
+			}
+			if len(paths) > maxIncrementalPaths || contains(".", paths) {
+				err = syncAll()
+			} else if err = sshConn.PushPaths(localDir, remoteDir, paths, matcher.Load()); err != nil {
+				fmt.Printf(" error: %v\n", err)
+			}
+			if err == nil {
+				sshConn.notifySynced(notifyFile, paths)
+			}
+			// A failure is most likely a connection about to be replaced.
+			missed = missed || err != nil
+		case <-reconnected:
+			if missed {
+				fmt.Println("Resyncing files after reconnect...")
+				missed = syncAll() != nil
+				if !missed {
+					sshConn.notifySynced(notifyFile, []string{"."})
+				}
+			}
+		}
+	}
 }
 
 // RunCommand runs a command on the remote pod.
@@ -208,7 +527,7 @@ func (sshConn *SSHConnection) RunCommands(commands []string) error {
 	stdoutColor, stderrColor := color.New(color.FgGreen), color.New(color.FgRed)
 
 	for _, command := range commands {
-		session, err := sshConn.client.NewSession()
+		session, err := sshConn.Client().NewSession()
 		if err != nil {
 			return fmt.Errorf("failed to create SSH session: %w", err)
 		}
@@ -228,20 +547,24 @@ func (sshConn *SSHConnection) RunCommands(commands []string) error {
 		go scanAndPrint(stderr, stderrColor, sshConn.podId, showPrefixInPodLogs)
 
 		// Run the command
-		fullCommand := strings.Join([]string{
-			"source /root/.bashrc",
-			"source /etc/rp_environment",
-			"while IFS= read -r -d '' line; do export \"$line\"; done < /proc/1/environ",
-			command,
-		}, " && ")
-
-		if err := session.Run(fullCommand); err != nil {
+		if err := session.Run(withPodEnvironment(command)); err != nil {
 			return fmt.Errorf("failed to run command %q: %w", command, err)
 		}
 	}
 	return nil
 }
 
+// withPodEnvironment prefixes command so that it sees the same environment as
+// the pod's main process, which a plain SSH session does not inherit.
+func withPodEnvironment(command string) string {
+	return strings.Join([]string{
+		"source /root/.bashrc",
+		"source /etc/rp_environment",
+		"while IFS= read -r -d '' line; do export \"$line\"; done < /proc/1/environ",
+		command,
+	}, " && ")
+}
+
 // Utility function to scan and print output from SSH sessions.
 func scanAndPrint(pipe io.Reader, color *color.Color, podID string, showPodIdPrefix bool) {
 	scanner := bufio.NewScanner(pipe)
@@ -254,48 +577,64 @@ func scanAndPrint(pipe io.Reader, color *color.Color, podID string, showPodIdPre
 }
 
 func PodSSHConnection(podId string) (*SSHConnection, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("getting user home directory: %w", err)
-	}
-
-	sshKeyPath := filepath.Join(homeDir, ".runpod", "ssh", "RunPod-Key-Go")
-	privateKeyBytes, err := os.ReadFile(sshKeyPath)
+	auth, err := newSSHAuth()
 	if err != nil {
-		return nil, fmt.Errorf("reading private SSH key from %s: %w", sshKeyPath, err)
-	}
-
-	privateKey, err := ssh.ParsePrivateKey(privateKeyBytes)
-	if err != nil {
-		return nil, fmt.Errorf("parsing private SSH key: %w", err)
+		return nil, err
 	}
 
 	//loop until pod ready
 
 	fmt.Print("Waiting for Pod to come online... ")
 	//look up ip and ssh port for pod id
-	var podIp string
+	var user, podIp string
 	var podPort int
 
 	startTime := time.Now()
-	for podIp, podPort, err = getPodSSHInfo(podId); err != nil && time.Since(startTime) < maxPollTime; {
+	for user, podIp, podPort, err = getPodSSHInfo(podId); err != nil && time.Since(startTime) < maxPollTime; {
 		time.Sleep(pollInterval)
-		podIp, podPort, err = getPodSSHInfo(podId)
+		user, podIp, podPort, err = getPodSSHInfo(podId)
 	}
 
 	if err != nil {
+		auth.close()
 		return nil, fmt.Errorf("failed to get SSH info for pod %s: %w", podId, err)
 	} else if time.Since(startTime) >= time.Duration(maxPollTime) {
+		auth.close()
 		return nil, fmt.Errorf("timeout waiting for pod %s to come online", podId)
 	}
 
+	if podIp == sshProxyHost {
+		fmt.Print("no public SSH port, connecting through the RunPod proxy... ")
+	}
+	client, err := dialPod(podId, auth, user, podIp, podPort)
+	if err != nil {
+		auth.close()
+		return nil, err
+	}
+
+	return &SSHConnection{podId: podId, user: user, client: client, podIp: podIp, podPort: podPort, connected: true, auth: auth, sshKeyPath: auth.usedIdentity()}, nil
+
+}
+
+func dialPod(podId string, auth *sshAuth, user string, podIp string, podPort int) (*ssh.Client, error) {
+	hostKeyIdentity := podId
+	if podIp == sshProxyHost {
+		hostKeyIdentity = sshProxyIdentity
+	}
+	hostKeyCallback, err := podHostKeyCallback(hostKeyIdentity)
+	if err != nil {
+		return nil, err
+	}
+
 	// Configure the SSH client
 	config := &ssh.ClientConfig{
-		User: "root",
+		User: user,
 		Auth: []ssh.AuthMethod{
-			ssh.PublicKeys(privateKey),
+			auth.method(),
 		},
-		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+		HostKeyCallback:   hostKeyCallback,
+		HostKeyAlgorithms: podHostKeyAlgorithms(hostKeyIdentity),
+		Timeout:           30 * time.Second,
 	}
 
 	// Connect to the SSH server
@@ -304,7 +643,5 @@ func PodSSHConnection(podId string) (*SSHConnection, error) {
 	if err != nil {
 		return nil, fmt.Errorf("establishing SSH connection to %s: %w", host, err)
 	}
-
-	return &SSHConnection{podId: podId, client: client, podIp: podIp, podPort: podPort, sshKeyPath: sshKeyPath}, nil
-
+	return client, nil
 }
@@ -0,0 +1,29 @@
+package project
+
+import (
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestShellCommand(t *testing.T) {
+	tests := [][]string{
+		{"plain"},
+		{"two words"},
+		{"it's", `"quoted"`, "$HOME", "`id`", "a\\b", ""},
+		{"-c", "print('x; y')", "*"},
+	}
+	for _, args := range tests {
+		// printf prints each argument the shell gives it on its own line
+		command := "printf '%s\\n' " + ShellCommand(args)
+		out, err := exec.Command("sh", "-c", command).Output()
+		if err != nil {
+			t.Fatalf("%s: %v", command, err)
+		}
+		got := strings.Split(strings.TrimSuffix(string(out), "\n"), "\n")
+		if !reflect.DeepEqual(got, args) {
+			t.Errorf("%s: shell saw %q, want %q", command, got, args)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package project
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// A supervised process runs detached from any SSH session under setsid and
+// nohup, so it survives a dropped connection. Its pid and output are kept in
+// dir on the pod, and followSupervised re-attaches to the output after a
+// reconnect.
+const supervisorHeredoc = "PODFLOW_SUPERVISED_SCRIPT"
+
+func supervisorFiles(dir string) (script, pidFile, logFile string) {
+	return path.Join(dir, "supervised.sh"), path.Join(dir, "supervised.pid"), path.Join(dir, "supervised.log")
+}
+
+// startSupervised replaces any process already supervised in dir with script.
+func (sshConn *SSHConnection) startSupervised(dir string, script string) error {
+	scriptFile, pidFile, logFile := supervisorFiles(dir)
+	command := fmt.Sprintf(`mkdir -p %[1]s
+		%[2]s
+		cat > %[3]s <<'%[4]s'
+%[5]s
+%[4]s
+		: > %[6]s
+		setsid nohup bash %[3]s > %[6]s 2>&1 < /dev/null &
+		echo $! > %[7]s`,
+		shellQuote(dir), stopSupervisedCommand(pidFile), shellQuote(scriptFile), supervisorHeredoc, script,
+		shellQuote(logFile), shellQuote(pidFile))
+	if err := sshConn.RunCommand(command); err != nil {
+		return fmt.Errorf("starting supervised process: %w", err)
+	}
+	return nil
+}
+
+// stopSupervised terminates the process supervised in dir and its children.
+func (sshConn *SSHConnection) stopSupervised(dir string) error {
+	_, pidFile, _ := supervisorFiles(dir)
+	return sshConn.RunCommand(stopSupervisedCommand(pidFile))
+}
+
+// stopSupervisedCommand signals the whole process group, which setsid made
+// the supervised pid the leader of, and waits up to 10 seconds for it to exit.
+func stopSupervisedCommand(pidFile string) string {
+	return fmt.Sprintf(`if [ -f %[1]s ] && kill -0 "$(cat %[1]s)" 2>/dev/null; then
+			kill -TERM -- "-$(cat %[1]s)" 2>/dev/null || true
+			for i in $(seq 10); do kill -0 "$(cat %[1]s)" 2>/dev/null || break; sleep 1; done
+		fi
+		rm -f %[1]s`, shellQuote(pidFile))
+}
+
+// followSupervised passes each line of output of the process supervised in
+// dir to handle until it exits. If the connection drops, it waits for
+// StayConnected to restore it and resumes from the last byte handled.
+func (sshConn *SSHConnection) followSupervised(dir string, handle func(line string)) error {
+	_, pidFile, logFile := supervisorFiles(dir)
+	var offset int64
+
+	for {
+		client := sshConn.Client()
+		session, err := client.NewSession()
+		if err == nil {
+			pipe, pipeErr := session.StdoutPipe()
+			if pipeErr != nil {
+				session.Close()
+				return fmt.Errorf("failed to get stdout pipe: %w", pipeErr)
+			}
+
+			// tail --pid exits once the supervised process is gone.
+			err = session.Start(fmt.Sprintf(`tail --pid="$(cat %s)" -c +%d -F %s 2>/dev/null`, shellQuote(pidFile), offset+1, shellQuote(logFile)))
+			if err == nil {
+				readErr := readLines(pipe, &offset, handle)
+				if readErr != nil {
+					// Nothing reads the rest of the output, which would keep
+					// tail, and Wait, from returning
+					session.Close()
+				}
+				if err = session.Wait(); err == nil {
+					err = readErr
+				}
+			}
+			session.Close()
+		}
+
+		var exitErr *ssh.ExitError
+		if err == nil || errors.As(err, &exitErr) {
+			return err
+		}
+
+		// Anything else means the connection went away.
+		for sshConn.Client() == client || !sshConn.Connected() {
+			sshConn.mu.Lock()
+			closed := sshConn.closed
+			sshConn.mu.Unlock()
+			if closed {
+				return err
+			}
+			time.Sleep(time.Second)
+		}
+		fmt.Println("Re-attached to the process on the Pod.")
+	}
+}
+
+// readLines passes each line of r to handle until r ends, without its line
+// ending, and adds the bytes read to offset. A last line without a newline
+// is passed on too.
+func readLines(r io.Reader, offset *int64, handle func(line string)) error {
+	reader := bufio.NewReader(r)
+	for {
+		line, err := reader.ReadString('\n')
+		*offset += int64(len(line))
+		if line != "" {
+			handle(strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r"))
+		}
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package project
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadLines(t *testing.T) {
+	long := strings.Repeat("\r45%|####      |", 10000)
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{name: "newlines", input: "a\nb\n", want: []string{"a", "b"}},
+		{name: "crlf", input: "a\r\nb\r\n", want: []string{"a", "b"}},
+		{name: "empty lines", input: "\n\na\n", want: []string{"", "", "a"}},
+		{name: "unterminated last line", input: "a\nb", want: []string{"a", "b"}},
+		{name: "line longer than a scanner token", input: long + "\ndone\n", want: []string{long, "done"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			offset := int64(3)
+			if err := readLines(strings.NewReader(tt.input), &offset, func(line string) { got = append(got, line) }); err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lines %q, want %q", got, tt.want)
+			}
+			// Resuming must start right after the last byte read
+			if want := int64(3 + len(tt.input)); offset != want {
+				t.Errorf("offset %d, want %d", offset, want)
+			}
+		})
+	}
+}
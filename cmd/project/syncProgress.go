@@ -0,0 +1,178 @@
+package project
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/pelletier/go-toml"
+	"github.com/schollz/progressbar/v3"
+	"golang.org/x/time/rate"
+)
+
+// defaultWarnSizeMB is the file size above which a sync warns, unless
+// warn_size_mb in the [sync] table of runpod.toml says otherwise.
+const defaultWarnSizeMB = 100
+
+// syncSetting returns key from the [sync] table of runpod.toml in the current
+// directory, or nil if it is not set.
+func syncSetting(key string) interface{} {
+	config, err := toml.LoadFile("runpod.toml")
+	if err != nil {
+		return nil
+	}
+	return config.GetPath([]string{"sync", key})
+}
+
+// syncPatterns returns key from the [sync] table of runpod.toml, which may be
+// a gitignore-style pattern or a list of them.
+func syncPatterns(key string) []string {
+	var patterns []string
+	switch v := syncSetting(key).(type) {
+	case string:
+		patterns = append(patterns, v)
+	case []interface{}:
+		for _, p := range v {
+			if s, ok := p.(string); ok {
+				patterns = append(patterns, s)
+			}
+		}
+	}
+	return patterns
+}
+
+// bandwidthLimit returns the upload limit in KiB/s from --bwlimit or, failing
+// that, bwlimit in the [sync] table of runpod.toml. 0 means unlimited.
+func bandwidthLimit() int {
+	if syncBandwidthLimit > 0 {
+		return syncBandwidthLimit
+	}
+	if limit, ok := syncSetting("bwlimit").(int64); ok && limit > 0 {
+		return int(limit)
+	}
+	return 0
+}
+
+// warnSize returns the size in bytes above which a file is reported as large.
+func warnSize() int64 {
+	if size, ok := syncSetting("warn_size_mb").(int64); ok && size > 0 {
+		return size << 20
+	}
+	return defaultWarnSizeMB << 20
+}
+
+// warnLargeFiles prints a warning for every file in sizes, keyed by path,
+// that is above warnSize.
+func warnLargeFiles(sizes map[string]int64) {
+	limit := warnSize()
+	var large []string
+	for p, size := range sizes {
+		if size > limit {
+			large = append(large, p)
+		}
+	}
+	sort.Strings(large)
+	for _, p := range large {
+		fmt.Printf("Warning: %s is %s; add it to .runpodignore if it does not need to be on the Pod.\n", p, formatBytes(sizes[p]))
+	}
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// syncMeter tracks the bytes a sync sends, showing them on a progress bar and
+// holding them to the bandwidth limit.
+type syncMeter struct {
+	bar     *progressbar.ProgressBar
+	limiter *rate.Limiter
+	files   int
+	sent    int64
+	start   time.Time
+	quiet   bool
+}
+
+func newSyncMeter(files int, totalBytes int64, quiet bool) *syncMeter {
+	m := &syncMeter{files: files, start: time.Now(), quiet: quiet}
+	if quiet {
+		m.bar = progressbar.DefaultBytesSilent(totalBytes)
+	} else {
+		m.bar = progressbar.DefaultBytes(totalBytes, fmt.Sprintf("Syncing %d files", files))
+	}
+	if limit := bandwidthLimit(); limit > 0 {
+		bytesPerSecond := limit * 1024
+		burst := bytesPerSecond
+		if burst < deltaChunkSize {
+			burst = deltaChunkSize
+		}
+		m.limiter = rate.NewLimiter(rate.Limit(bytesPerSecond), burst)
+	}
+	return m
+}
+
+// add accounts for n bytes sent, waiting first if that would exceed the limit.
+func (m *syncMeter) add(n int) {
+	if m.limiter != nil && n > 0 {
+		m.limiter.WaitN(context.Background(), n)
+	}
+	m.progress(int64(n))
+}
+
+// progress accounts for n bytes sent by something that limits itself.
+func (m *syncMeter) progress(n int64) {
+	m.sent += n
+	m.bar.Add64(n)
+}
+
+// skip moves the bar past n bytes that turned out not to need sending.
+func (m *syncMeter) skip(n int64) {
+	m.bar.Add64(n)
+}
+
+// finish completes the bar and prints a summary of what was sent.
+func (m *syncMeter) finish() {
+	m.bar.Finish()
+	if !m.quiet {
+		fmt.Printf("Synced %d files (%s sent) in %s\n", m.files, formatBytes(m.sent), time.Since(m.start).Round(100*time.Millisecond))
+	}
+}
+
+// meteredFS is a fileSystem whose files count what is read from them on a syncMeter.
+type meteredFS struct {
+	fileSystem
+	meter *syncMeter
+}
+
+func (m meteredFS) Open(name string) (io.ReadSeekCloser, error) {
+	f, err := m.fileSystem.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	return &meteredFile{ReadSeekCloser: f, meter: m.meter}, nil
+}
+
+type meteredFile struct {
+	io.ReadSeekCloser
+	meter *syncMeter
+}
+
+func (f *meteredFile) Read(p []byte) (int, error) {
+	// Keep reads within what the limiter can grant at once.
+	if f.meter.limiter != nil && len(p) > f.meter.limiter.Burst() {
+		p = p[:f.meter.limiter.Burst()]
+	}
+	n, err := f.ReadSeekCloser.Read(p)
+	f.meter.add(n)
+	return n, err
+}
@@ -30,6 +30,9 @@ name = "%s"
 # ports                  - Ports to expose and their protocols. Configure as needed for your application.
 #
 # container_disk_size_gb - Disk space allocated to the container. Adjust according to your needs.
+#
+# on_exit                - What to do with the development pod when 'podflow dev' ends: "keep", "stop" or "terminate".
+#                        - "stop" and "terminate" save the venv to the network volume first. Override with --on-exit.
 
 uuid = "%s"
 base_image = "runpod/base:0.6.2-cuda%s"
@@ -47,6 +50,7 @@ gpu_count = 1
 volume_mount_path = "/runpod-volume"
 ports = "4040/http, 7270/http, 22/tcp" # FileBrowser, FastAPI, SSH
 container_disk_size_gb = 100
+on_exit = "keep"
 
 [project.env_vars]
 # Set environment variables for the pod.
@@ -90,6 +94,33 @@ package_manager = "pip"	# Supported package managers: 'pip', 'uv'
 python_version = "%s"
 handler_path = "src/handler.py"
 requirements_path = "builder/requirements.txt"
+
+[ssh]
+# identity_file - Private key(s) to connect to the development pod with, tried after any keys in ssh-agent.
+#               - Accepts a path or a list of paths. Encrypted keys prompt for their passphrase.
+
+# identity_file = "~/.ssh/id_ed25519"
+
+[sync]
+# engine - "builtin" syncs over SFTP and needs nothing installed; "rsync" needs rsync here and on the pod.
+#        - Defaults to rsync when it is installed locally.
+
+# engine = "builtin"
+
+# pull   - Files to copy back from the pod during and after 'podflow dev', and with 'podflow pull'.
+#        - gitignore-style patterns. Pulled files are never pushed to the pod.
+
+# pull = ["outputs/**"]
+
+# bwlimit      - Limit uploads to this many KiB/s, e.g. to keep a large checkpoint from saturating your connection.
+# warn_size_mb - Warn about synced files larger than this. Defaults to 100.
+
+# bwlimit = 2048
+# warn_size_mb = 100
+
+# allow_secrets - Files that look like secrets (.env, *.pem, private keys, tokens) are not synced unless listed here.
+
+# allow_secrets = [".env.dev"]
 `
 
 	// Format the template with dynamic content
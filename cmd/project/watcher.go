@@ -0,0 +1,133 @@
+package project
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// syncDebounce is how long the tree must be quiet before a batch is sent.
+	syncDebounce = 200 * time.Millisecond
+	// syncMaxDelay caps how long a steady stream of events can hold a batch back.
+	syncMaxDelay = 2 * time.Second
+)
+
+// watchTree watches root and every directory below it that is not ignored,
+// and sends batches of changed paths, relative to root and slash-separated,
+// once events stop arriving for syncDebounce. It returns when the watcher fails.
+func watchTree(root string, ignore func(relPath string, isDir bool) bool, batches chan<- []string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating file watcher: %w", err)
+	}
+	defer watcher.Close()
+
+	rel := func(path string) string {
+		relPath, err := filepath.Rel(root, path)
+		if err != nil {
+			return ""
+		}
+		return filepath.ToSlash(relPath)
+	}
+
+	// addTree watches dir and its subdirectories, returning the files found so
+	// that a directory created (or moved in) between events is synced in full.
+	addTree := func(dir string) []string {
+		var found []string
+		filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil
+			}
+			relPath := rel(path)
+			if path != root && ignore(relPath, d.IsDir()) {
+				if d.IsDir() {
+					return filepath.SkipDir
+				}
+				return nil
+			}
+			if d.IsDir() {
+				if err := watcher.Add(path); err != nil {
+					fmt.Printf("Unable to watch %s: %v\n", path, err)
+				}
+				return nil
+			}
+			found = append(found, relPath)
+			return nil
+		})
+		return found
+	}
+	addTree(root)
+
+	pending := map[string]bool{}
+	var timer <-chan time.Time
+	var firstPending time.Time
+
+	flush := func() {
+		paths := make([]string, 0, len(pending))
+		for p := range pending {
+			paths = append(paths, p)
+		}
+		sort.Strings(paths)
+		pending = map[string]bool{}
+		timer = nil
+		batches <- paths
+	}
+
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return nil
+			}
+			if event.Op == fsnotify.Chmod {
+				continue
+			}
+			relPath := rel(event.Name)
+			if relPath == "" || relPath == "." {
+				continue
+			}
+			info, statErr := os.Lstat(event.Name)
+			isDir := statErr == nil && info.IsDir()
+			if ignore(relPath, isDir) {
+				continue
+			}
+
+			if isDir && event.Has(fsnotify.Create) {
+				for _, p := range addTree(event.Name) {
+					pending[p] = true
+				}
+			}
+			pending[relPath] = true
+
+			if len(pending) == 1 || timer == nil {
+				firstPending = time.Now()
+			}
+			wait := syncDebounce
+			if remaining := syncMaxDelay - time.Since(firstPending); remaining < wait {
+				wait = remaining
+			}
+			timer = time.After(wait)
+
+		case <-timer:
+			flush()
+
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return nil
+			}
+			// Overflows lose events, so ask for everything to be checked.
+			if err == fsnotify.ErrEventOverflow {
+				pending["."] = true
+				flush()
+				continue
+			}
+			return fmt.Errorf("watching files: %w", err)
+		}
+	}
+}
@@ -7,13 +7,15 @@ import (
 	"github.com/spf13/cobra"
 )
 
+// removeCmd keeps the old verb-first paths working; use the resource groups instead.
 var removeCmd = &cobra.Command{
-	Use:   "remove [command]",
-	Short: "remove a resource",
-	Long:  "remove a resource in runpod.io",
+	Use:    "remove [command]",
+	Short:  "remove a resource",
+	Long:   "remove a resource in runpod.io",
+	Hidden: true,
 }
 
 func init() {
-	removeCmd.AddCommand(pod.RemovePodCmd)
+	removeCmd.AddCommand(deprecatedAlias("pod [podId]", pod.RemovePodCmd, "podflow pod rm"))
 	removeCmd.AddCommand(pods.RemovePodsCmd)
 }
@@ -5,7 +5,10 @@ import (
 	"os"
 
 	"cli/api"
+	"cli/cmd/cp"
+	"cli/cmd/pod"
 	"cli/cmd/project"
+	"cli/cmd/top"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -38,6 +41,10 @@ var (
 		ID:    "hub",
 		Title: "Hub Operations:",
 	}
+	resourceGroup = &cobra.Group{
+		ID:    "resource",
+		Title: "Resource Commands:",
+	}
 )
 
 func GetRootCmd() *cobra.Command {
@@ -53,6 +60,7 @@ func init() {
 func registerCommands() {
 	rootCmd.AddGroup(projectGroup)
 	rootCmd.AddGroup(hubGroup)
+	rootCmd.AddGroup(resourceGroup)
 
 	// PodFlow
 	rootCmd.AddCommand(project.ForkProjectCmd)
@@ -60,12 +68,30 @@ func registerCommands() {
 	rootCmd.AddCommand(project.StartProjectCmd)
 	rootCmd.AddCommand(project.DeployProjectCmd)
 	rootCmd.AddCommand(project.PublishProjectCmd)
+	rootCmd.AddCommand(project.PullProjectCmd)
+	rootCmd.AddCommand(ignoreCmd)
+	rootCmd.AddCommand(project.AttachCmd)
+	rootCmd.AddCommand(project.LogsCmd)
+	rootCmd.AddCommand(sessionCmd)
+
+	// Resources
+	rootCmd.AddCommand(podCmd)
+	rootCmd.AddCommand(endpointCmd)
+	rootCmd.AddCommand(cloudCmd)
+	rootCmd.AddCommand(sshCmd)
+	rootCmd.AddCommand(execCmd)
+	rootCmd.AddCommand(pod.PortForwardCmd)
+	rootCmd.AddCommand(cp.CopyCmd)
+	rootCmd.AddCommand(top.TopCmd)
+
+	// Deprecated verb-first paths
+	rootCmd.AddCommand(getCmd)
+	rootCmd.AddCommand(removeCmd)
 
 	rootCmd.AddCommand(configCmd)
 	rootCmd.AddCommand(versionCmd)
 	//rootCmd.AddCommand(projectCmd)
 	rootCmd.AddCommand(updateCmd)
-	//rootCmd.AddCommand(sshCmd)
 
 	// Version
 	rootCmd.Version = version
@@ -80,6 +106,21 @@ func registerCommands() {
 	rootCmd.PersistentFlags().Lookup("api-url").Hidden = true
 }
 
+// deprecatedAlias exposes target under an old command path. The alias shares
+// the target's flags, so both paths behave identically apart from the warning.
+func deprecatedAlias(use string, target *cobra.Command, replacement string) *cobra.Command {
+	alias := &cobra.Command{
+		Use:        use,
+		Short:      target.Short,
+		Long:       target.Long,
+		Args:       target.Args,
+		Run:        target.Run,
+		Deprecated: fmt.Sprintf("use %q instead.", replacement),
+	}
+	alias.Flags().AddFlagSet(target.Flags())
+	return alias
+}
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
 func Execute(ver string) {
@@ -0,0 +1,21 @@
+package cmd
+
+import (
+	"cli/cmd/project"
+
+	"github.com/spf13/cobra"
+)
+
+// sessionCmd groups the commands for managing detached dev sessions
+var sessionCmd = &cobra.Command{
+	Use:     "session",
+	Short:   "Manage detached development sessions",
+	Long:    `Manage development sessions started with 'podflow dev --detach', which keep running after the terminal is closed.`,
+	GroupID: "project",
+}
+
+func init() {
+	sessionCmd.AddCommand(project.SessionListCmd)
+	sessionCmd.AddCommand(project.SessionStopCmd)
+	sessionCmd.AddCommand(project.SessionSyncCmd)
+}
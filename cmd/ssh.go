@@ -6,13 +6,29 @@ import (
 	"github.com/spf13/cobra"
 )
 
+var forwardAgent bool
+
 var sshCmd = &cobra.Command{
-	Use:   "ssh",
+	Use:   "ssh [podId|project]",
+	Args:  cobra.MaximumNArgs(1),
 	Short: "SSH keys and commands",
-	Long:  "SSH key management and connection to pods",
+	Long: `Open an interactive shell on a pod, or manage SSH keys.
+
+Without arguments, connects to the dev pod of the project in the current directory.`,
+	GroupID: "resource",
+	Run: func(cmd *cobra.Command, args []string) {
+		target := ""
+		if len(args) == 1 {
+			target = args[0]
+		}
+		cobra.CheckErr(ssh.Connect(target, forwardAgent))
+	},
 }
 
 func init() {
 	sshCmd.AddCommand(ssh.ListKeysCmd)
 	sshCmd.AddCommand(ssh.AddKeyCmd)
+	sshCmd.AddCommand(ssh.ConfigCmd)
+
+	sshCmd.Flags().BoolVarP(&forwardAgent, "forward-agent", "A", false, "forward the local SSH agent to the pod")
 }
@@ -5,51 +5,67 @@ import (
 	"fmt"
 	"os"
 	"strings"
-	"text/tabwriter"
 
 	"cli/api"
+	"cli/format"
 
 	"github.com/spf13/cobra"
 )
 
+var output format.Options
+
 // ListKeysCmd defines the command to list all SSH keys for the current user.
 var ListKeysCmd = &cobra.Command{
 	Use:   "list-keys",
 	Short: "List all SSH keys",
 	Long:  `List all the SSH keys associated with the current user's account.`,
 	Run: func(cmd *cobra.Command, args []string) {
+		printer, err := output.Printer()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return
+		}
+
 		_, keys, err := api.GetPublicSSHKeys()
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Error getting SSH keys: %v\n", err)
 			return
 		}
 
-		if len(keys) == 0 {
+		if len(keys) == 0 && !output.IsStructured() {
 			fmt.Println("No SSH keys found.")
 			return
 		}
 
-		displaySSHKeys(keys)
+		if err := displaySSHKeys(printer, keys); err != nil {
+			fmt.Fprintf(os.Stderr, "Failed to write keys: %v\n", err)
+		}
 	},
 }
 
-// displaySSHKeys prints the SSH keys in a tabulated format.
-func displaySSHKeys(keys []api.SSHKey) {
-	w := tabwriter.NewWriter(os.Stdout, 8, 8, 2, ' ', 0)
+var keyColumns = []format.Column{
+	{Header: "Name", Field: "name"},
+	{Header: "Type", Field: "type"},
+	{Header: "Fingerprint", Field: "fingerprint"},
+	{Header: "Key", Field: "key", Wide: true},
+}
 
-	fmt.Fprintln(w, "Name\tType\tFingerprint")
-	fmt.Fprintln(w, "----\t----\t-----------")
+// displaySSHKeys prints the SSH keys using the selected output format.
+func displaySSHKeys(printer format.Printer, keys []api.SSHKey) error {
+	list := &format.List{Columns: keyColumns, Items: keys}
+	if keys == nil {
+		list.Items = []api.SSHKey{}
+	}
 
 	for _, key := range keys {
-		if key.Name == "" {
-			key.Name = "N/A"
+		name := key.Name
+		if name == "" {
+			name = "N/A"
 		}
-		fmt.Fprintf(w, "%s\t%s\t%s\n", key.Name, key.Type, key.Fingerprint)
+		list.Rows = append(list.Rows, []string{name, key.Type, key.Fingerprint, strings.TrimSpace(key.Key)})
 	}
 
-	if err := w.Flush(); err != nil {
-		fmt.Fprintf(os.Stderr, "Failed to write keys to table: %v\n", err)
-	}
+	return printer.Print(os.Stdout, list)
 }
 
 var AddKeyCmd = &cobra.Command{
@@ -91,6 +107,42 @@ var AddKeyCmd = &cobra.Command{
 	},
 }
 
+var writeConfig bool
+
+var ConfigCmd = &cobra.Command{
+	Use:   "config",
+	Args:  cobra.ExactArgs(0),
+	Short: "Generate SSH config entries for running pods",
+	Long: `Print a "Host podflow-<name>" block for every running pod, for use with ssh,
+VS Code Remote-SSH or JetBrains Gateway.
+
+With --write the blocks replace the contents of ~/.runpod/ssh_config, and an
+Include line for it is added to ~/.ssh/config if missing. Re-run it whenever pods
+change; entries for pods that are no longer running are dropped.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		pods, err := api.GetPods()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error getting pods: %v\n", err)
+			return
+		}
+		if !writeConfig {
+			config, err := GenerateSSHConfig(pods)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error generating SSH config: %v\n", err)
+				return
+			}
+			fmt.Print(config)
+			return
+		}
+		path, err := WriteSSHConfig(pods)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing SSH config: %v\n", err)
+			return
+		}
+		fmt.Printf("SSH config for running pods written to %s\n", path)
+	},
+}
+
 func confirmAddKey() bool {
 	fmt.Print("Would you like to add an SSH key to your account? (y/n) ")
 	scanner := bufio.NewScanner(os.Stdin)
@@ -110,6 +162,10 @@ func promptKeyName() string {
 }
 
 func init() {
+	output.AddFlags(ListKeysCmd)
+
 	AddKeyCmd.Flags().String("key", "", "The public key to add.")
 	AddKeyCmd.Flags().String("key-file", "", "The file containing the public key to add.")
+
+	ConfigCmd.Flags().BoolVar(&writeConfig, "write", false, "Write ~/.runpod/ssh_config and include it from ~/.ssh/config.")
 }
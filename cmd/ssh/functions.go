@@ -1,6 +1,9 @@
 package ssh
 
 import (
+	"bytes"
+	"cli/api"
+	"cli/cmd/project"
 	"crypto/rand"
 	"crypto/rsa"
 	"crypto/x509"
@@ -8,6 +11,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"golang.org/x/crypto/ssh"
@@ -63,3 +67,135 @@ func GenerateSSHKeyPair(keyName string) ([]byte, error) {
 	fmt.Printf("SSH key pair generated: %s (private), %s (public)\n", privateKeyPath, publicKeyPath)
 	return publicKeyBytes, nil
 }
+
+// Connect opens an interactive shell on the pod or project dev pod named by target.
+func Connect(target string, forwardAgent bool) error {
+	podId, err := project.ResolvePod(target)
+	if err != nil {
+		return err
+	}
+	return project.OpenShell(podId, forwardAgent)
+}
+
+var hostNameUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// sshHostAlias turns a pod name into a Host alias such as "podflow-my-project-dev".
+func sshHostAlias(name string) string {
+	alias := strings.Trim(hostNameUnsafe.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	if alias == "" {
+		return ""
+	}
+	return "podflow-" + alias
+}
+
+// GenerateSSHConfig returns ssh_config Host blocks for every running pod,
+// without touching any files.
+func GenerateSSHConfig(pods []*api.Pod) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting user home directory: %w", err)
+	}
+	identityFile := filepath.Join(homeDir, ".runpod", "ssh", "RunPod-Key-Go")
+	for _, f := range project.IdentityFiles() {
+		if _, err := os.Stat(f); err == nil {
+			identityFile = f
+			break
+		}
+	}
+	var buf bytes.Buffer
+	buf.WriteString("# Generated by 'podflow ssh config --write'; changes will be overwritten.\n")
+	seen := map[string]bool{}
+	for _, pod := range pods {
+		if pod.DesiredStatus != "RUNNING" || pod.Runtime == nil {
+			continue
+		}
+		// Pods without a public SSH port are reached through the proxy gateway.
+		user, hostName, port := "", "", 0
+		for _, p := range pod.Runtime.Ports {
+			if p.PrivatePort == 22 && p.IsIpPublic {
+				user, hostName, port = "root", p.Ip, p.PublicPort
+			}
+		}
+		if hostName == "" && pod.Machine != nil && pod.Machine.PodHostId != "" {
+			user, hostName, port = pod.Machine.PodHostId, "ssh.runpod.io", 22
+		}
+		if hostName == "" {
+			continue
+		}
+
+		// ssh adds the key of a new pod to a file of the pod's own, so that
+		// an address reused by another pod does not look like a changed key.
+		knownHosts, err := project.PodKnownHostsFile(pod.Id)
+		if err != nil {
+			return "", err
+		}
+
+		alias := sshHostAlias(pod.Name)
+		if alias == "" || seen[alias] {
+			alias = "podflow-" + pod.Id
+		}
+		seen[alias] = true
+
+		fmt.Fprintf(&buf, "\n# %s (%s)\n", pod.Name, pod.Id)
+		fmt.Fprintf(&buf, "Host %s\n", alias)
+		fmt.Fprintf(&buf, "    HostName %s\n", hostName)
+		fmt.Fprintf(&buf, "    Port %d\n", port)
+		fmt.Fprintf(&buf, "    User %s\n", user)
+		fmt.Fprintf(&buf, "    IdentityFile \"%s\"\n", identityFile)
+		fmt.Fprintf(&buf, "    IdentitiesOnly yes\n")
+		fmt.Fprintf(&buf, "    UserKnownHostsFile \"%s\"\n", knownHosts)
+		fmt.Fprintf(&buf, "    StrictHostKeyChecking accept-new\n")
+	}
+	return buf.String(), nil
+}
+
+// WriteSSHConfig replaces ~/.runpod/ssh_config with the config for pods,
+// along with the known_hosts files it uses, and makes sure ~/.ssh/config
+// includes it. It returns the path of the generated file.
+func WriteSSHConfig(pods []*api.Pod) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("getting user home directory: %w", err)
+	}
+	config, err := GenerateSSHConfig(pods)
+	if err != nil {
+		return "", err
+	}
+	podIds := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		podIds = append(podIds, pod.Id)
+	}
+	if err := project.PreparePodKnownHosts(podIds); err != nil {
+		return "", fmt.Errorf("preparing known_hosts files: %w", err)
+	}
+
+	configPath := filepath.Join(homeDir, ".runpod", "ssh_config")
+	if err := os.MkdirAll(filepath.Dir(configPath), 0700); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(configPath, []byte(config), 0600); err != nil {
+		return "", err
+	}
+
+	// Include must come before any Host block in ~/.ssh/config to apply globally.
+	userConfigPath := filepath.Join(homeDir, ".ssh", "config")
+	userConfig, err := os.ReadFile(userConfigPath)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	include := fmt.Sprintf("Include \"%s\"", configPath)
+	for _, line := range strings.Split(string(userConfig), "\n") {
+		if strings.TrimSpace(line) == include {
+			return configPath, nil
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(userConfigPath), 0700); err != nil {
+		return "", err
+	}
+	userConfig = append([]byte(include+"\n\n"), userConfig...)
+	if err := os.WriteFile(userConfigPath, userConfig, 0600); err != nil {
+		return "", err
+	}
+	fmt.Printf("Added '%s' to %s\n", include, userConfigPath)
+	return configPath, nil
+}
@@ -0,0 +1,67 @@
+package ssh
+
+import (
+	"cli/api"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGenerateSSHConfigKnownHosts(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	// podflow has verified the key of pod1 before; pod0 was terminated
+	shared := "[1.2.3.4]:10022 ssh-ed25519 AAAAC3NzaC1lZDI1NTE5AAAAIJdD7y3aLq454yWBdwLWbieU1ebz9/cu7/QEXn9OIeZJ pod1\n"
+	os.MkdirAll(filepath.Join(home, ".runpod", "known_hosts.d"), 0700)
+	os.WriteFile(filepath.Join(home, ".runpod", "known_hosts"), []byte(shared), 0600)
+	os.WriteFile(filepath.Join(home, ".runpod", "known_hosts.d", "pod0"), []byte("stale\n"), 0600)
+
+	running := func(id, name string, port int) *api.Pod {
+		return &api.Pod{Id: id, Name: name, DesiredStatus: "RUNNING", Runtime: &api.Runtime{Ports: []*api.PodRuntimePorts{
+			{Ip: "1.2.3.4", IsIpPublic: true, PrivatePort: 22, PublicPort: port},
+		}}}
+	}
+	pods := []*api.Pod{running("pod1", "Train", 10022), running("pod2", "Train", 10023)}
+	config, err := GenerateSSHConfig(pods)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Printing the config leaves the files alone
+	knownHostsDir := filepath.Join(home, ".runpod", "known_hosts.d")
+	if entries, _ := os.ReadDir(knownHostsDir); len(entries) != 1 || entries[0].Name() != "pod0" {
+		t.Errorf("generating the config changed %s: %v", knownHostsDir, entries)
+	}
+
+	path, err := WriteSSHConfig(pods)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if written, _ := os.ReadFile(path); string(written) != config {
+		t.Errorf("written config differs from the generated one:\n%s", written)
+	}
+	for _, want := range []string{
+		"Host podflow-train\n",
+		"Host podflow-pod2\n",
+		`UserKnownHostsFile "` + filepath.Join(knownHostsDir, "pod1") + `"`,
+		`UserKnownHostsFile "` + filepath.Join(knownHostsDir, "pod2") + `"`,
+		"StrictHostKeyChecking accept-new",
+	} {
+		if !strings.Contains(config, want) {
+			t.Errorf("config does not contain %q:\n%s", want, config)
+		}
+	}
+
+	if data, _ := os.ReadFile(filepath.Join(knownHostsDir, "pod1")); string(data) != shared {
+		t.Errorf("known_hosts of pod1 = %q, want the verified key %q", data, shared)
+	}
+	if data, err := os.ReadFile(filepath.Join(knownHostsDir, "pod2")); err != nil || len(data) != 0 {
+		t.Errorf("known_hosts of pod2 = %q, %v; want an empty file", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(knownHostsDir, "pod0")); err == nil {
+		t.Error("known_hosts of the terminated pod0 was kept")
+	}
+}
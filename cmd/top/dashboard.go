@@ -0,0 +1,339 @@
+package top
+
+import (
+	"cli/api"
+	"cli/cmd/project"
+	"cli/format"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	termbox "github.com/burl/termbox-go"
+	"github.com/pelletier/go-toml"
+)
+
+// projectInfo identifies the project in the current directory, if any.
+type projectInfo struct {
+	name string
+	uuid string
+}
+
+// snapshot is the result of one refresh of pods and endpoints.
+type snapshot struct {
+	pods      []*api.Pod
+	endpoints []*api.Endpoint
+	err       error
+}
+
+type dashboard struct {
+	interval  time.Duration
+	project   *projectInfo
+	pods      []*api.Pod
+	endpoints []*api.Endpoint
+	selected  int
+	updated   time.Time
+	fetchErr  error
+	message   string
+	confirm   string // id of the pod awaiting terminate confirmation
+
+	refreshed chan snapshot
+	results   chan string
+}
+
+func newDashboard(interval time.Duration) *dashboard {
+	return &dashboard{
+		interval:  interval,
+		project:   currentProject(),
+		refreshed: make(chan snapshot, 1),
+		results:   make(chan string, 4),
+	}
+}
+
+// currentProject reads runpod.toml from the working directory, if present.
+func currentProject() *projectInfo {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	config, err := toml.LoadFile(filepath.Join(cwd, "runpod.toml"))
+	if err != nil {
+		return nil
+	}
+	name, _ := config.Get("name").(string)
+	uuid, _ := config.GetPath([]string{"project", "uuid"}).(string)
+	if uuid == "" {
+		return nil
+	}
+	return &projectInfo{name: name, uuid: uuid}
+}
+
+func (d *dashboard) fetch() {
+	var s snapshot
+	s.pods, s.err = api.GetPods()
+	if s.err == nil {
+		s.endpoints, s.err = api.GetEndpoints()
+	}
+	d.refreshed <- s
+}
+
+func (d *dashboard) run() error {
+	if err := termbox.Init(); err != nil {
+		return fmt.Errorf("initializing terminal: %w", err)
+	}
+	defer termbox.Close()
+
+	// The poller waits for an ack after every event so that the terminal can be
+	// handed over to an SSH session without PollEvent running underneath it.
+	events := make(chan termbox.Event)
+	ack := make(chan struct{})
+	go func() {
+		for {
+			events <- termbox.PollEvent()
+			<-ack
+		}
+	}()
+
+	go d.fetch()
+	ticker := time.NewTicker(d.interval)
+	defer ticker.Stop()
+
+	for {
+		d.draw()
+		select {
+		case s := <-d.refreshed:
+			d.updated = time.Now()
+			d.fetchErr = s.err
+			if s.err == nil {
+				d.pods, d.endpoints = s.pods, s.endpoints
+				if d.selected >= len(d.pods) {
+					d.selected = len(d.pods) - 1
+				}
+				if d.selected < 0 {
+					d.selected = 0
+				}
+			}
+		case <-ticker.C:
+			go d.fetch()
+		case msg := <-d.results:
+			d.message = msg
+			go d.fetch()
+		case ev := <-events:
+			quit := d.handle(ev)
+			ack <- struct{}{}
+			if quit {
+				return nil
+			}
+		}
+	}
+}
+
+// handle processes one terminal event and reports whether to quit.
+func (d *dashboard) handle(ev termbox.Event) bool {
+	if ev.Type != termbox.EventKey {
+		return false
+	}
+
+	if d.confirm != "" {
+		if ev.Ch == 'y' || ev.Ch == 'Y' {
+			d.runAction("terminating", d.confirm, func(id string) error {
+				_, err := api.RemovePod(id)
+				return err
+			})
+		} else {
+			d.message = "terminate cancelled"
+		}
+		d.confirm = ""
+		return false
+	}
+
+	pod := d.selectedPod()
+	switch {
+	case ev.Key == termbox.KeyEsc || ev.Key == termbox.KeyCtrlC || ev.Ch == 'q':
+		return true
+	case ev.Key == termbox.KeyArrowUp || ev.Ch == 'k':
+		if d.selected > 0 {
+			d.selected--
+		}
+	case ev.Key == termbox.KeyArrowDown || ev.Ch == 'j':
+		if d.selected < len(d.pods)-1 {
+			d.selected++
+		}
+	case pod == nil:
+		return false
+	case ev.Ch == 's':
+		d.runAction("stopping", pod.Id, func(id string) error {
+			_, err := api.StopPod(id)
+			return err
+		})
+	case ev.Ch == 'r':
+		d.runAction("starting", pod.Id, func(id string) error {
+			_, err := api.StartOnDemandPod(id)
+			return err
+		})
+	case ev.Ch == 't':
+		d.confirm = pod.Id
+	case ev.Key == termbox.KeyEnter:
+		d.message = d.ssh(pod.Id)
+	}
+	return false
+}
+
+func (d *dashboard) selectedPod() *api.Pod {
+	if d.selected < 0 || d.selected >= len(d.pods) {
+		return nil
+	}
+	return d.pods[d.selected]
+}
+
+// runAction calls the API in the background and reports back through d.results.
+func (d *dashboard) runAction(verb string, podId string, action func(string) error) {
+	d.message = fmt.Sprintf("%s pod %s...", verb, podId)
+	go func() {
+		if err := action(podId); err != nil {
+			d.results <- fmt.Sprintf("%s pod %s failed: %v", verb, podId, err)
+			return
+		}
+		d.results <- fmt.Sprintf("%s pod %s: done", verb, podId)
+	}()
+}
+
+// ssh suspends the dashboard for an interactive session on the pod.
+func (d *dashboard) ssh(podId string) string {
+	termbox.Close()
+	runErr := project.OpenShell(podId, false)
+	if err := termbox.Init(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error re-initializing terminal: %v\n", err)
+		os.Exit(1)
+	}
+	if runErr != nil {
+		return fmt.Sprintf("ssh session to %s ended: %v", podId, runErr)
+	}
+	return fmt.Sprintf("ssh session to %s closed", podId)
+}
+
+func (d *dashboard) isProjectPod(p *api.Pod) bool {
+	return d.project != nil && project.IsProjectPod(p, d.project.uuid)
+}
+
+func (d *dashboard) isProjectEndpoint(e *api.Endpoint) bool {
+	return d.project != nil && project.IsProjectEndpoint(e, d.project.uuid)
+}
+
+func (d *dashboard) draw() {
+	termbox.Clear(termbox.ColorDefault, termbox.ColorDefault)
+	width, height := termbox.Size()
+	y := 0
+
+	title := "podflow top"
+	if d.project != nil {
+		title += fmt.Sprintf(" | project: %s (%s)", d.project.name, d.project.uuid)
+	}
+	if !d.updated.IsZero() {
+		title += fmt.Sprintf(" | updated %s, every %s", d.updated.Format("15:04:05"), d.interval)
+	}
+	drawLine(0, y, width, title, termbox.ColorDefault|termbox.AttrBold, termbox.ColorDefault)
+	y += 2
+
+	var totalCost float32
+	for _, p := range d.pods {
+		if p.DesiredStatus == "RUNNING" {
+			totalCost += p.CostPerHr
+		}
+	}
+	drawLine(0, y, width, fmt.Sprintf("PODS (%d, running $%.3f/hr)", len(d.pods), totalCost), termbox.ColorCyan|termbox.AttrBold, termbox.ColorDefault)
+	y++
+	drawLine(0, y, width, podRow(" ", "ID", "NAME", "STATUS", "GPU", "$/HR", "UPTIME"), termbox.AttrBold, termbox.ColorDefault)
+	y++
+
+	// Leave room for the endpoint section and the footer.
+	podRows := height - y - 4 - min(len(d.endpoints), 5) - 2
+	if podRows < 1 {
+		podRows = 1
+	}
+	first := 0
+	if d.selected >= podRows {
+		first = d.selected - podRows + 1
+	}
+	for i := first; i < len(d.pods) && i < first+podRows; i++ {
+		p := d.pods[i]
+		marker := " "
+		if d.isProjectPod(p) {
+			marker = "*"
+		}
+		gpu := fmt.Sprintf("%d", p.GpuCount)
+		if p.Machine != nil {
+			gpu = fmt.Sprintf("%d %s", p.GpuCount, p.Machine.GpuDisplayName)
+		}
+		row := podRow(marker, p.Id, p.Name, p.DesiredStatus, gpu, fmt.Sprintf("%.3f", p.CostPerHr), format.Uptime(p.UptimeSeconds))
+		fg, bg := statusColor(p.DesiredStatus), termbox.ColorDefault
+		if i == d.selected {
+			fg, bg = termbox.ColorBlack, termbox.ColorWhite
+		}
+		drawLine(0, y, width, row, fg, bg)
+		y++
+	}
+	y++
+
+	drawLine(0, y, width, fmt.Sprintf("ENDPOINTS (%d)", len(d.endpoints)), termbox.ColorCyan|termbox.AttrBold, termbox.ColorDefault)
+	y++
+	for _, e := range d.endpoints {
+		if y >= height-2 {
+			break
+		}
+		marker := " "
+		if d.isProjectEndpoint(e) {
+			marker = "*"
+		}
+		row := fmt.Sprintf("%s %-16s %-40s %-20s workers %d-%d", marker, e.Id, truncate(e.Name, 40), truncate(e.GpuIds, 20), e.WorkersMin, e.WorkersMax)
+		drawLine(0, y, width, row, termbox.ColorDefault, termbox.ColorDefault)
+		y++
+	}
+
+	status := d.message
+	if d.confirm != "" {
+		status = fmt.Sprintf("terminate pod %s? this deletes its container disk [y/N]", d.confirm)
+	} else if d.fetchErr != nil {
+		status = fmt.Sprintf("refresh failed: %v", d.fetchErr)
+	}
+	drawLine(0, height-2, width, status, termbox.ColorYellow, termbox.ColorDefault)
+	drawLine(0, height-1, width, "[up/down] select  [s] stop  [r] start  [t] terminate  [enter] ssh  [q] quit   * = current project", termbox.ColorDefault|termbox.AttrReverse, termbox.ColorDefault)
+
+	termbox.Flush()
+}
+
+func podRow(marker, id, name, status, gpu, cost, up string) string {
+	return fmt.Sprintf("%s %-16s %-32s %-9s %-28s %7s %9s", marker, truncate(id, 16), truncate(name, 32), status, truncate(gpu, 28), cost, up)
+}
+
+func statusColor(status string) termbox.Attribute {
+	switch status {
+	case "RUNNING":
+		return termbox.ColorGreen
+	case "EXITED", "TERMINATED":
+		return termbox.ColorRed
+	}
+	return termbox.ColorYellow
+}
+
+func truncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "…"
+}
+
+// drawLine writes s at row y, padding with bg up to width so selections span the screen.
+func drawLine(x, y, width int, s string, fg, bg termbox.Attribute) {
+	for _, r := range s {
+		if x >= width {
+			return
+		}
+		termbox.SetCell(x, y, r, fg, bg)
+		x++
+	}
+	for ; x < width; x++ {
+		termbox.SetCell(x, y, ' ', fg, bg)
+	}
+}
@@ -0,0 +1,37 @@
+package top
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var refreshInterval time.Duration
+
+var TopCmd = &cobra.Command{
+	Use:     "top",
+	Args:    cobra.ExactArgs(0),
+	Short:   "Live dashboard of pods and endpoints",
+	GroupID: "resource",
+	Long: `Full-screen dashboard listing your pods and endpoints with status, GPU, $/hr and uptime.
+
+Keys:
+  up/down, j/k  select a pod
+  s             stop the selected pod
+  r             start (resume) the selected pod
+  t             terminate the selected pod (asks for confirmation)
+  enter         open an SSH session to the selected pod
+  q, esc        quit`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if refreshInterval < time.Second {
+			cobra.CheckErr(fmt.Errorf("--interval must be at least 1s"))
+		}
+		d := newDashboard(refreshInterval)
+		cobra.CheckErr(d.run())
+	},
+}
+
+func init() {
+	TopCmd.Flags().DurationVar(&refreshInterval, "interval", 5*time.Second, "how often to refresh pods and endpoints")
+}
@@ -0,0 +1,155 @@
+package watch
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sort"
+	"time"
+)
+
+// Resource is the watched state of a single pod or endpoint. Only the values
+// in Fields are compared between polls, so callers decide what counts as a change.
+type Resource struct {
+	Kind   string
+	Id     string
+	Name   string
+	Fields map[string]string
+}
+
+// Event describes one change between two polls.
+type Event struct {
+	Time  time.Time `json:"time"`
+	Type  string    `json:"type"` // ADDED, MODIFIED or DELETED
+	Kind  string    `json:"kind"`
+	Id    string    `json:"id"`
+	Name  string    `json:"name"`
+	Field string    `json:"field,omitempty"`
+	From  string    `json:"from,omitempty"`
+	To    string    `json:"to,omitempty"`
+}
+
+// Options controls how often resources are polled and how events are printed.
+type Options struct {
+	Interval   time.Duration
+	MaxBackoff time.Duration
+	JSON       bool // print events as JSON lines instead of text
+	// Baseline holds the resources the caller already listed; the first poll
+	// happens an interval later and only reports changes from it. Without a
+	// baseline, the resources found by the first poll are reported as ADDED.
+	Baseline []Resource
+}
+
+// Diff returns the events that turn prev into next, in a stable order.
+func Diff(prev, next []Resource, now time.Time) []Event {
+	before := map[string]Resource{}
+	for _, r := range prev {
+		before[r.Id] = r
+	}
+	after := map[string]bool{}
+
+	events := []Event{}
+	for _, r := range next {
+		after[r.Id] = true
+		old, ok := before[r.Id]
+		if !ok {
+			events = append(events, Event{Time: now, Type: "ADDED", Kind: r.Kind, Id: r.Id, Name: r.Name})
+			continue
+		}
+		fields := make([]string, 0, len(r.Fields))
+		for field := range r.Fields {
+			fields = append(fields, field)
+		}
+		sort.Strings(fields)
+		for _, field := range fields {
+			if old.Fields[field] != r.Fields[field] {
+				events = append(events, Event{
+					Time: now, Type: "MODIFIED", Kind: r.Kind, Id: r.Id, Name: r.Name,
+					Field: field, From: old.Fields[field], To: r.Fields[field],
+				})
+			}
+		}
+	}
+	for _, r := range prev {
+		if !after[r.Id] {
+			events = append(events, Event{Time: now, Type: "DELETED", Kind: r.Kind, Id: r.Id, Name: r.Name})
+		}
+	}
+	return events
+}
+
+// Run polls fetch until interrupted and writes one line per change to out.
+// Failed polls are retried with exponential backoff up to opts.MaxBackoff;
+// successful ones are always opts.Interval apart.
+func Run(opts Options, fetch func() ([]Resource, error), out io.Writer) error {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if opts.Interval <= 0 {
+		opts.Interval = 5 * time.Second
+	}
+	if opts.MaxBackoff < opts.Interval {
+		opts.MaxBackoff = max(2*time.Minute, opts.Interval)
+	}
+
+	prev := opts.Baseline
+	wait := opts.Interval
+	if prev != nil && !sleep(ctx, wait) {
+		return nil
+	}
+	for {
+		next, err := fetch()
+		if err != nil {
+			wait *= 2
+			if wait > opts.MaxBackoff {
+				wait = opts.MaxBackoff
+			}
+			fmt.Fprintf(os.Stderr, "watch: %v (retrying in %s)\n", err, wait)
+		} else {
+			wait = opts.Interval
+			for _, e := range Diff(prev, next, time.Now()) {
+				if err := printEvent(out, e, opts.JSON); err != nil {
+					return err
+				}
+			}
+			prev = next
+		}
+		if !sleep(ctx, wait) {
+			return nil
+		}
+	}
+}
+
+// sleep waits for d and reports whether ctx is still live afterwards.
+func sleep(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
+
+func printEvent(out io.Writer, e Event, asJSON bool) error {
+	if asJSON {
+		return json.NewEncoder(out).Encode(e)
+	}
+	name := e.Name
+	if name == "" {
+		name = "-"
+	}
+	prefix := fmt.Sprintf("%s %s %s (%s)", e.Time.Format("15:04:05"), e.Kind, e.Id, name)
+	var err error
+	switch e.Type {
+	case "ADDED":
+		_, err = fmt.Fprintf(out, "%s added\n", prefix)
+	case "DELETED":
+		_, err = fmt.Fprintf(out, "%s removed\n", prefix)
+	default:
+		_, err = fmt.Fprintf(out, "%s %s: %s -> %s\n", prefix, e.Field, e.From, e.To)
+	}
+	return err
+}
@@ -0,0 +1,122 @@
+package watch
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func pod(id, status, cost string) Resource {
+	return Resource{Kind: "pod", Id: id, Name: "name-" + id, Fields: map[string]string{"status": status, "costPerHr": cost}}
+}
+
+func TestDiff(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name string
+		prev []Resource
+		next []Resource
+		want []Event
+	}{
+		{name: "nothing", want: []Event{}},
+		{
+			name: "unchanged",
+			prev: []Resource{pod("a", "RUNNING", "0.690")},
+			next: []Resource{pod("a", "RUNNING", "0.690")},
+			want: []Event{},
+		},
+		{
+			name: "added",
+			prev: nil,
+			next: []Resource{pod("a", "RUNNING", "0.690"), pod("b", "EXITED", "0.000")},
+			want: []Event{
+				{Time: now, Type: "ADDED", Kind: "pod", Id: "a", Name: "name-a"},
+				{Time: now, Type: "ADDED", Kind: "pod", Id: "b", Name: "name-b"},
+			},
+		},
+		{
+			name: "modified fields in name order",
+			prev: []Resource{pod("a", "RUNNING", "0.690")},
+			next: []Resource{pod("a", "EXITED", "0.000")},
+			want: []Event{
+				{Time: now, Type: "MODIFIED", Kind: "pod", Id: "a", Name: "name-a", Field: "costPerHr", From: "0.690", To: "0.000"},
+				{Time: now, Type: "MODIFIED", Kind: "pod", Id: "a", Name: "name-a", Field: "status", From: "RUNNING", To: "EXITED"},
+			},
+		},
+		{
+			name: "new field",
+			prev: []Resource{{Kind: "pod", Id: "a", Fields: map[string]string{}}},
+			next: []Resource{{Kind: "pod", Id: "a", Fields: map[string]string{"status": "RUNNING"}}},
+			want: []Event{
+				{Time: now, Type: "MODIFIED", Kind: "pod", Id: "a", Field: "status", To: "RUNNING"},
+			},
+		},
+		{
+			name: "deleted after changes",
+			prev: []Resource{pod("a", "RUNNING", "0.690"), pod("b", "RUNNING", "0.200")},
+			next: []Resource{pod("c", "RUNNING", "1.000"), pod("a", "RUNNING", "0.700")},
+			want: []Event{
+				{Time: now, Type: "ADDED", Kind: "pod", Id: "c", Name: "name-c"},
+				{Time: now, Type: "MODIFIED", Kind: "pod", Id: "a", Name: "name-a", Field: "costPerHr", From: "0.690", To: "0.700"},
+				{Time: now, Type: "DELETED", Kind: "pod", Id: "b", Name: "name-b"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff(tt.prev, tt.next, now); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff =\n%+v\nwant\n%+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintEvent(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 3, 4, 0, time.UTC)
+	tests := []struct {
+		event  Event
+		asJSON bool
+		want   string
+	}{
+		{Event{Time: now, Type: "ADDED", Kind: "pod", Id: "a"}, false, "12:03:04 pod a (-) added\n"},
+		{Event{Time: now, Type: "DELETED", Kind: "endpoint", Id: "e", Name: "api"}, false, "12:03:04 endpoint e (api) removed\n"},
+		{Event{Time: now, Type: "MODIFIED", Kind: "pod", Id: "a", Name: "x", Field: "status", From: "RUNNING", To: "EXITED"}, false,
+			"12:03:04 pod a (x) status: RUNNING -> EXITED\n"},
+		{Event{Time: now, Type: "ADDED", Kind: "pod", Id: "a", Name: "x"}, true,
+			`{"time":"2024-05-01T12:03:04Z","type":"ADDED","kind":"pod","id":"a","name":"x"}` + "\n"},
+	}
+	for _, tt := range tests {
+		var buf bytes.Buffer
+		if err := printEvent(&buf, tt.event, tt.asJSON); err != nil {
+			t.Fatal(err)
+		}
+		if got := buf.String(); got != tt.want {
+			t.Errorf("printEvent(%+v) = %q, want %q", tt.event, got, tt.want)
+		}
+	}
+}
+
+func TestRunStartsFromBaseline(t *testing.T) {
+	polls := 0
+	fetch := func() ([]Resource, error) {
+		polls++
+		if polls == 2 {
+			// Stop Run the way Ctrl-C does
+			p, _ := os.FindProcess(os.Getpid())
+			p.Signal(os.Interrupt)
+		}
+		return []Resource{pod("a", "EXITED", "0.690")}, nil
+	}
+	var buf bytes.Buffer
+	opts := Options{Interval: time.Millisecond, Baseline: []Resource{pod("a", "RUNNING", "0.690")}}
+	if err := Run(opts, fetch, &buf); err != nil {
+		t.Fatal(err)
+	}
+	got := buf.String()
+	if strings.Contains(got, "added") || strings.Count(got, "status: RUNNING -> EXITED") != 1 {
+		t.Errorf("output %q: want only the change from the baseline", got)
+	}
+}
@@ -0,0 +1,186 @@
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// jsonPathPrinter implements the subset of kubectl-style JSONPath templates
+// that scripts actually use: text with {expressions} such as
+// {.items[*].id}, {.items[0].name} and {"\n"} literals. Multiple results of a
+// wildcard are joined with a single space.
+type jsonPathPrinter struct {
+	segments []jsonPathSegment
+}
+
+type jsonPathSegment struct {
+	literal string
+	path    []jsonPathStep // nil for literal segments
+}
+
+type jsonPathStep struct {
+	field    string
+	index    int
+	wildcard bool
+	isIndex  bool
+}
+
+func newJSONPathPrinter(expr string) (*jsonPathPrinter, error) {
+	p := &jsonPathPrinter{}
+	for len(expr) > 0 {
+		open := strings.IndexByte(expr, '{')
+		if open < 0 {
+			p.segments = append(p.segments, jsonPathSegment{literal: expr})
+			break
+		}
+		if open > 0 {
+			p.segments = append(p.segments, jsonPathSegment{literal: expr[:open]})
+		}
+		end := strings.IndexByte(expr[open:], '}')
+		if end < 0 {
+			return nil, fmt.Errorf("jsonpath: unclosed '{' in %q", expr)
+		}
+		inner := strings.TrimSpace(expr[open+1 : open+end])
+		expr = expr[open+end+1:]
+
+		if strings.HasPrefix(inner, `"`) {
+			lit, err := strconv.Unquote(inner)
+			if err != nil {
+				return nil, fmt.Errorf("jsonpath: bad string literal %s", inner)
+			}
+			p.segments = append(p.segments, jsonPathSegment{literal: lit})
+			continue
+		}
+		steps, err := parseJSONPath(inner)
+		if err != nil {
+			return nil, err
+		}
+		p.segments = append(p.segments, jsonPathSegment{path: steps})
+	}
+	return p, nil
+}
+
+func parseJSONPath(expr string) ([]jsonPathStep, error) {
+	expr = strings.TrimPrefix(expr, "$")
+	steps := []jsonPathStep{}
+	for len(expr) > 0 {
+		switch expr[0] {
+		case '.':
+			expr = expr[1:]
+			n := strings.IndexAny(expr, ".[")
+			if n < 0 {
+				n = len(expr)
+			}
+			if n > 0 {
+				steps = append(steps, jsonPathStep{field: expr[:n]})
+			}
+			expr = expr[n:]
+		case '[':
+			end := strings.IndexByte(expr, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("jsonpath: unclosed '[' in %q", expr)
+			}
+			inner := expr[1:end]
+			expr = expr[end+1:]
+			if inner == "*" {
+				steps = append(steps, jsonPathStep{wildcard: true})
+				continue
+			}
+			i, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("jsonpath: unsupported index [%s]", inner)
+			}
+			steps = append(steps, jsonPathStep{index: i, isIndex: true})
+		default:
+			return nil, fmt.Errorf("jsonpath: unexpected %q, expressions start with '.'", expr)
+		}
+	}
+	return steps, nil
+}
+
+func (p *jsonPathPrinter) Print(w io.Writer, list *List) error {
+	data, err := generic(document(list))
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	for _, seg := range p.segments {
+		if seg.path == nil {
+			buf.WriteString(seg.literal)
+			continue
+		}
+		results := []interface{}{data}
+		for _, step := range seg.path {
+			results = applyJSONPathStep(results, step)
+		}
+		for i, r := range results {
+			if i > 0 {
+				buf.WriteByte(' ')
+			}
+			buf.WriteString(jsonPathString(r))
+		}
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
+
+func applyJSONPathStep(in []interface{}, step jsonPathStep) []interface{} {
+	out := []interface{}{}
+	for _, v := range in {
+		switch {
+		case step.wildcard:
+			switch t := v.(type) {
+			case []interface{}:
+				out = append(out, t...)
+			case map[string]interface{}:
+				// In key order, so the output does not change between runs
+				keys := make([]string, 0, len(t))
+				for k := range t {
+					keys = append(keys, k)
+				}
+				sort.Strings(keys)
+				for _, k := range keys {
+					out = append(out, t[k])
+				}
+			}
+		case step.isIndex:
+			if arr, ok := v.([]interface{}); ok {
+				i := step.index
+				if i < 0 {
+					i += len(arr)
+				}
+				if i >= 0 && i < len(arr) {
+					out = append(out, arr[i])
+				}
+			}
+		default:
+			if m, ok := v.(map[string]interface{}); ok {
+				if child, ok := m[step.field]; ok {
+					out = append(out, child)
+				}
+			}
+		}
+	}
+	return out
+}
+
+func jsonPathString(v interface{}) string {
+	switch t := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return t
+	case float64:
+		return strconv.FormatFloat(t, 'f', -1, 64)
+	case map[string]interface{}, []interface{}:
+		raw, _ := json.Marshal(t)
+		return string(raw)
+	default:
+		return fmt.Sprint(t)
+	}
+}
@@ -0,0 +1,67 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestJSONPath(t *testing.T) {
+	list := &List{Items: []map[string]interface{}{
+		{"id": "a1", "gpuCount": 1, "cost": 0.69, "labels": map[string]string{"team": "ml", "role": "dev", "env": "test"}},
+		{"id": "b2", "gpuCount": 2, "cost": 3.5, "labels": map[string]string{}},
+		{"id": "c3", "gpuCount": 0, "cost": nil},
+	}}
+	tests := []struct {
+		expr string
+		want string
+	}{
+		{expr: "{.items[*].id}", want: "a1 b2 c3"},
+		{expr: "{$.items[*].id}", want: "a1 b2 c3"},
+		{expr: "{.items[0].id}", want: "a1"},
+		{expr: "{.items[-1].id}", want: "c3"},
+		{expr: "{.items[9].id}", want: ""},
+		{expr: "{.items[*].gpuCount}", want: "1 2 0"},
+		{expr: "{.items[*].cost}", want: "0.69 3.5 "},
+		{expr: "{.items[0].missing}", want: ""},
+		{expr: "{.items[1].labels}", want: "{}"},
+		// Map values come in key order, whatever order Go iterates them in
+		{expr: "{.items[0].labels[*]}", want: "test dev ml"},
+		{expr: `{.items[0].id}{"\t"}{.items[1].id}{"\n"}`, want: "a1\tb2\n"},
+		{expr: "id={.items[2].id}", want: "id=c3"},
+		{expr: "{ .items[0].id }", want: "a1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			// Repeat the wildcard over a map, whose order Go randomizes
+			for i := 0; i < 10; i++ {
+				p, err := newJSONPathPrinter(tt.expr)
+				if err != nil {
+					t.Fatal(err)
+				}
+				var buf bytes.Buffer
+				if err := p.Print(&buf, list); err != nil {
+					t.Fatal(err)
+				}
+				if got := buf.String(); got != tt.want {
+					t.Fatalf("got %q, want %q", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestJSONPathErrors(t *testing.T) {
+	for _, expr := range []string{
+		"{.items",
+		"{.items[0}",
+		"{.items[a]}",
+		"{.items[?(@.id)]}",
+		"{items}",
+		"{range .items[*]}{.id}{end}",
+		`{"unterminated}`,
+	} {
+		if _, err := newJSONPathPrinter(expr); err == nil {
+			t.Errorf("%s: expected an error", expr)
+		}
+	}
+}
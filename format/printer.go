@@ -0,0 +1,252 @@
+package format
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+)
+
+// Column describes one field of a listing. Field is the stable,
+// machine-readable name of the item field it shows, used for CSV headers;
+// Header is what humans see. A column combining several item fields, such
+// as "1 RTX 4090" from gpuCount and gpuType, lists them in Fields instead.
+type Column struct {
+	Header string
+	Field  string
+	Fields []string
+	Wide   bool // only shown with -o wide
+}
+
+// fields returns the names of the item fields behind the column.
+func (c Column) fields() []string {
+	if len(c.Fields) > 0 {
+		return c.Fields
+	}
+	return []string{c.Field}
+}
+
+// List is everything a printer needs to render the output of a get/ls command.
+// Rows holds the human-formatted cells, one per column, while Items holds the
+// typed records used for the structured formats (json, yaml, jsonpath, go-template).
+type List struct {
+	Columns []Column
+	Rows    [][]string
+	Items   interface{}
+}
+
+// Printer renders a List in one output format.
+type Printer interface {
+	Print(w io.Writer, list *List) error
+}
+
+// Options holds the output flags shared by all listing commands.
+type Options struct {
+	Output    string
+	NoHeaders bool
+}
+
+// AddFlags registers -o/--output and --no-headers on a listing command.
+func (o *Options) AddFlags(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&o.Output, "output", "o", "table", "output format: table|wide|json|yaml|csv|jsonpath=...|go-template=...")
+	cmd.Flags().BoolVar(&o.NoHeaders, "no-headers", false, "omit column headers from table, wide and csv output")
+}
+
+// Printer returns the printer selected by the --output flag.
+func (o *Options) Printer() (Printer, error) {
+	name, arg, _ := strings.Cut(o.Output, "=")
+	switch name {
+	case "", "table":
+		return &tablePrinter{noHeaders: o.NoHeaders}, nil
+	case "wide":
+		return &tablePrinter{wide: true, noHeaders: o.NoHeaders}, nil
+	case "json":
+		return &jsonPrinter{}, nil
+	case "yaml":
+		return &yamlPrinter{}, nil
+	case "csv":
+		return &csvPrinter{noHeaders: o.NoHeaders}, nil
+	case "jsonpath":
+		if arg == "" {
+			return nil, fmt.Errorf("jsonpath output requires an expression, e.g. -o jsonpath='{.items[*].id}'")
+		}
+		return newJSONPathPrinter(arg)
+	case "go-template":
+		if arg == "" {
+			return nil, fmt.Errorf("go-template output requires a template, e.g. -o go-template='{{range .items}}{{.id}}{{end}}'")
+		}
+		tmpl, err := template.New("output").Parse(arg)
+		if err != nil {
+			return nil, fmt.Errorf("parsing go-template: %w", err)
+		}
+		return &templatePrinter{tmpl: tmpl}, nil
+	}
+	return nil, fmt.Errorf("unknown output format %q", o.Output)
+}
+
+// IsStructured reports whether the selected format is meant for machines
+// rather than humans, so callers can suppress decorative messages.
+func (o *Options) IsStructured() bool {
+	name, _, _ := strings.Cut(o.Output, "=")
+	return name != "" && name != "table" && name != "wide"
+}
+
+// document wraps the items in the stable top-level shape shared by the
+// structured formats: {"items": [...]}.
+func document(list *List) map[string]interface{} {
+	items := list.Items
+	if items == nil {
+		items = []interface{}{}
+	}
+	return map[string]interface{}{"items": items}
+}
+
+// generic round-trips v through JSON so that templates and jsonpath see the
+// same stable field names as -o json.
+func generic(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var out interface{}
+	err = json.Unmarshal(raw, &out)
+	return out, err
+}
+
+type tablePrinter struct {
+	wide      bool
+	noHeaders bool
+}
+
+func (p *tablePrinter) Print(w io.Writer, list *List) error {
+	var header []string
+	var keep []int
+	for i, c := range list.Columns {
+		if c.Wide && !p.wide {
+			continue
+		}
+		header = append(header, c.Header)
+		keep = append(keep, i)
+	}
+
+	tb := tablewriter.NewWriter(w)
+	if !p.noHeaders {
+		tb.SetHeader(header)
+	}
+	for _, row := range list.Rows {
+		cells := make([]string, 0, len(keep))
+		for _, i := range keep {
+			if i < len(row) {
+				cells = append(cells, row[i])
+			} else {
+				cells = append(cells, "")
+			}
+		}
+		tb.Append(cells)
+	}
+	TableDefaults(tb)
+	tb.Render()
+	return nil
+}
+
+type jsonPrinter struct{}
+
+func (p *jsonPrinter) Print(w io.Writer, list *List) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(document(list))
+}
+
+type yamlPrinter struct{}
+
+func (p *yamlPrinter) Print(w io.Writer, list *List) error {
+	// Going through JSON keeps the field names identical to -o json and,
+	// unlike a plain map, preserves the field order of the items.
+	raw, err := json.Marshal(document(list))
+	if err != nil {
+		return err
+	}
+	var node yaml.Node
+	if err := yaml.Unmarshal(raw, &node); err != nil {
+		return err
+	}
+	blockStyle(&node)
+	enc := yaml.NewEncoder(w)
+	enc.SetIndent(2)
+	if err := enc.Encode(&node); err != nil {
+		return err
+	}
+	return enc.Close()
+}
+
+// blockStyle clears the flow style yaml.v3 infers from JSON input.
+func blockStyle(node *yaml.Node) {
+	node.Style &^= yaml.FlowStyle
+	if node.Kind == yaml.ScalarNode && node.Tag == "!!str" {
+		node.Style &^= yaml.DoubleQuotedStyle
+	}
+	for _, child := range node.Content {
+		blockStyle(child)
+	}
+}
+
+type csvPrinter struct {
+	noHeaders bool
+}
+
+// Print writes one record per item with the raw values of the columns' item
+// fields, named as in -o json, rather than the cells formatted for the table.
+func (p *csvPrinter) Print(w io.Writer, list *List) error {
+	data, err := generic(document(list))
+	if err != nil {
+		return err
+	}
+	var fields []string
+	for _, c := range list.Columns {
+		fields = append(fields, c.fields()...)
+	}
+
+	cw := csv.NewWriter(w)
+	if !p.noHeaders {
+		if err := cw.Write(fields); err != nil {
+			return err
+		}
+	}
+	items, _ := data.(map[string]interface{})["items"].([]interface{})
+	for _, item := range items {
+		values, _ := item.(map[string]interface{})
+		record := make([]string, len(fields))
+		for i, field := range fields {
+			record[i] = jsonPathString(values[field])
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+type templatePrinter struct {
+	tmpl *template.Template
+}
+
+func (p *templatePrinter) Print(w io.Writer, list *List) error {
+	data, err := generic(document(list))
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := p.tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("executing go-template: %w", err)
+	}
+	_, err = buf.WriteTo(w)
+	return err
+}
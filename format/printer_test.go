@@ -0,0 +1,162 @@
+package format
+
+import (
+	"bytes"
+	"testing"
+)
+
+type testItem struct {
+	Id       string            `json:"id"`
+	GpuCount int               `json:"gpuCount"`
+	GpuType  string            `json:"gpuType"`
+	Cost     float64           `json:"costPerHr"`
+	Labels   map[string]string `json:"labels"`
+}
+
+func testList() *List {
+	return &List{
+		Columns: []Column{
+			{Header: "ID", Field: "id"},
+			{Header: "GPU", Fields: []string{"gpuCount", "gpuType"}},
+			{Header: "$/hr", Field: "costPerHr", Wide: true},
+			{Header: "Labels", Field: "labels", Wide: true},
+		},
+		Rows: [][]string{
+			{"a1", "1 RTX 4090", "0.690", "role=dev"},
+			{"b2", "2 A100, SXM", "3.500", ""},
+		},
+		Items: []testItem{
+			{Id: "a1", GpuCount: 1, GpuType: "RTX 4090", Cost: 0.69, Labels: map[string]string{"role": "dev"}},
+			{Id: "b2", GpuCount: 2, GpuType: "A100, SXM", Cost: 3.5},
+		},
+	}
+}
+
+func TestPrinters(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		list    *List
+		want    string
+	}{
+		{
+			name:    "table",
+			options: Options{Output: "table"},
+			list:    testList(),
+			want: "ID\tGPU         \n" +
+				"a1\t1 RTX 4090 \t\n" +
+				"b2\t2 A100, SXM\t\n",
+		},
+		{
+			name:    "wide without headers",
+			options: Options{Output: "wide", NoHeaders: true},
+			list:    testList(),
+			want: "a1\t1 RTX 4090 \t0.690\trole=dev\t\n" +
+				"b2\t2 A100, SXM\t3.500\t        \t\n",
+		},
+		{
+			name:    "json",
+			options: Options{Output: "json"},
+			list:    &List{Items: []testItem{{Id: "a1", GpuCount: 1}}},
+			want: `{
+  "items": [
+    {
+      "id": "a1",
+      "gpuCount": 1,
+      "gpuType": "",
+      "costPerHr": 0,
+      "labels": null
+    }
+  ]
+}
+`,
+		},
+		{
+			name:    "json without items",
+			options: Options{Output: "json"},
+			list:    &List{},
+			want:    "{\n  \"items\": []\n}\n",
+		},
+		{
+			name:    "yaml",
+			options: Options{Output: "yaml"},
+			list:    &List{Items: []testItem{{Id: "a1", GpuType: "RTX 4090", Labels: map[string]string{"role": "dev"}}}},
+			want: `items:
+  - id: a1
+    gpuCount: 0
+    gpuType: RTX 4090
+    costPerHr: 0
+    labels:
+      role: dev
+`,
+		},
+		{
+			name:    "csv has raw values named as in json",
+			options: Options{Output: "csv"},
+			list:    testList(),
+			want: "id,gpuCount,gpuType,costPerHr,labels\n" +
+				"a1,1,RTX 4090,0.69,\"{\"\"role\"\":\"\"dev\"\"}\"\n" +
+				"b2,2,\"A100, SXM\",3.5,\n",
+		},
+		{
+			name:    "csv without headers",
+			options: Options{Output: "csv", NoHeaders: true},
+			list:    testList(),
+			want: "a1,1,RTX 4090,0.69,\"{\"\"role\"\":\"\"dev\"\"}\"\n" +
+				"b2,2,\"A100, SXM\",3.5,\n",
+		},
+		{
+			name:    "go-template",
+			options: Options{Output: "go-template={{range .items}}{{.id}}={{.gpuType}};{{end}}"},
+			list:    testList(),
+			want:    "a1=RTX 4090;b2=A100, SXM;",
+		},
+		{
+			name:    "jsonpath",
+			options: Options{Output: `jsonpath={.items[*].id}{"\n"}`},
+			list:    testList(),
+			want:    "a1 b2\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			printer, err := tt.options.Printer()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var buf bytes.Buffer
+			if err := printer.Print(&buf, tt.list); err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrinterErrors(t *testing.T) {
+	for _, output := range []string{"xml", "jsonpath", "jsonpath=", "go-template", "go-template={{.items"} {
+		if _, err := (&Options{Output: output}).Printer(); err == nil {
+			t.Errorf("-o %s: expected an error", output)
+		}
+	}
+}
+
+func TestIsStructured(t *testing.T) {
+	tests := map[string]bool{
+		"":                  false,
+		"table":             false,
+		"wide":              false,
+		"json":              true,
+		"yaml":              true,
+		"csv":               true,
+		"jsonpath={.items}": true,
+		"go-template={{.}}": true,
+	}
+	for output, want := range tests {
+		if got := (&Options{Output: output}).IsStructured(); got != want {
+			t.Errorf("IsStructured(%q) = %v, want %v", output, got, want)
+		}
+	}
+}
@@ -0,0 +1,19 @@
+package format
+
+import (
+	"fmt"
+	"time"
+)
+
+// Uptime formats a pod's uptime for tables: "-" when it is not running,
+// minutes and hours below a day ("2h5m0s"), then days and hours ("3d4h").
+func Uptime(seconds int) string {
+	if seconds <= 0 {
+		return "-"
+	}
+	d := time.Duration(seconds) * time.Second
+	if d >= 24*time.Hour {
+		return fmt.Sprintf("%dd%dh", int(d.Hours())/24, int(d.Hours())%24)
+	}
+	return d.Truncate(time.Minute).String()
+}
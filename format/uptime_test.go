@@ -0,0 +1,20 @@
+package format
+
+import "testing"
+
+func TestUptime(t *testing.T) {
+	tests := map[int]string{
+		-1:                    "-",
+		0:                     "-",
+		59:                    "0s",
+		90:                    "1m0s",
+		2*3600 + 5*60 + 30:    "2h5m0s",
+		24 * 3600:             "1d0h",
+		3*86400 + 4*3600 + 59: "3d4h",
+	}
+	for seconds, want := range tests {
+		if got := Uptime(seconds); got != want {
+			t.Errorf("Uptime(%d) = %q, want %q", seconds, got, want)
+		}
+	}
+}
@@ -0,0 +1,335 @@
+// Package ignore implements gitignore pattern matching for .runpodignore files.
+//
+// Patterns follow gitignore(5): blank lines and lines starting with # are
+// skipped, ! negates, a trailing / matches directories only, a / anywhere
+// else anchors the pattern to the directory of the file it is in, and **
+// matches across directories. Files in subdirectories apply to paths below
+// them and take precedence over files higher up. As in git, nothing inside an
+// ignored directory can be re-included.
+package ignore
+
+import (
+	"bufio"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Rule is a single pattern and where it came from.
+type Rule struct {
+	Source  string // file the pattern was read from, or "" for built-in defaults
+	Line    int    // 0 for patterns not read from a file
+	Pattern string
+
+	base    string // directory the pattern is relative to, "" for the root
+	negate  bool
+	dirOnly bool
+	re      *regexp.Regexp
+}
+
+func (r *Rule) String() string {
+	if r.Source == "" {
+		return fmt.Sprintf("default pattern %q", r.Pattern)
+	}
+	if r.Line == 0 {
+		return fmt.Sprintf("%s: %s", r.Source, r.Pattern)
+	}
+	return fmt.Sprintf("%s:%d: %s", r.Source, r.Line, r.Pattern)
+}
+
+// Matcher holds the rules for one tree. Paths passed to it are relative to
+// the tree root and slash-separated.
+type Matcher struct {
+	rules []*Rule
+}
+
+// New returns a Matcher for patterns that apply to the whole tree.
+func New(patterns []string) (*Matcher, error) {
+	m := &Matcher{}
+	for i, pattern := range patterns {
+		if err := m.add("", "", i+1, pattern); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Load returns a Matcher for root with the default patterns followed by every
+// file named fileName in root and its subdirectories. Directories that are
+// already ignored are not searched.
+func Load(root string, fileName string, defaults []string) (*Matcher, error) {
+	m, err := New(defaults)
+	if err != nil {
+		return nil, err
+	}
+	err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		rel = filepath.ToSlash(rel)
+		if rel == "." {
+			rel = ""
+		} else if m.Match(rel, true) {
+			return filepath.SkipDir
+		}
+		return m.addFile(filepath.Join(p, fileName), rel)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Add appends patterns that apply to the whole tree and take precedence over
+// every rule already loaded. source describes where they came from.
+func (m *Matcher) Add(source string, patterns []string) error {
+	for _, pattern := range patterns {
+		if err := m.add(source, "", 0, pattern); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// With returns a copy of m with patterns added as by Add, leaving m itself
+// unchanged for anyone still using it.
+func (m *Matcher) With(source string, patterns []string) (*Matcher, error) {
+	c := &Matcher{rules: append([]*Rule(nil), m.rules...)}
+	if err := c.Add(source, patterns); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Literal returns a pattern that matches relPath, relative to the tree root,
+// and nothing else.
+func Literal(relPath string) string {
+	var b strings.Builder
+	b.WriteByte('/')
+	for _, c := range filepath.ToSlash(relPath) {
+		if strings.ContainsRune(`\*?[`, c) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(c)
+	}
+	pattern := b.String()
+	if strings.HasSuffix(pattern, " ") {
+		pattern = pattern[:len(pattern)-1] + `\ `
+	}
+	return pattern
+}
+
+func (m *Matcher) addFile(file string, base string) error {
+	f, err := os.Open(file)
+	if os.IsNotExist(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		if err := m.add(file, base, line, scanner.Text()); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (m *Matcher) add(source string, base string, line int, pattern string) error {
+	rule := &Rule{Source: source, Line: line, Pattern: strings.TrimSpace(pattern), base: base}
+
+	p := trimTrailingSpace(strings.TrimPrefix(pattern, "\ufeff"))
+	if p == "" || strings.HasPrefix(p, "#") {
+		return nil
+	}
+	if strings.HasPrefix(p, "!") {
+		rule.negate = true
+		p = p[1:]
+	} else if strings.HasPrefix(p, `\!`) || strings.HasPrefix(p, `\#`) {
+		p = p[1:]
+	}
+	if strings.HasSuffix(p, "/") {
+		rule.dirOnly = true
+		p = strings.TrimRight(p, "/")
+	}
+	if p == "" {
+		return nil
+	}
+
+	// A slash anywhere but the end anchors the pattern to base.
+	anchored := strings.Contains(p, "/")
+	p = strings.TrimPrefix(p, "/")
+
+	expr, err := translate(p)
+	if err != nil {
+		if source == "" || line == 0 {
+			return fmt.Errorf("invalid ignore pattern %q: %w", pattern, err)
+		}
+		return fmt.Errorf("%s:%d: invalid ignore pattern %q: %w", source, line, pattern, err)
+	}
+	if !anchored {
+		expr = "(?:.*/)?" + expr
+	}
+	rule.re = regexp.MustCompile("^" + expr + "$")
+	m.rules = append(m.rules, rule)
+	return nil
+}
+
+// trimTrailingSpace removes trailing spaces unless they are escaped with a backslash.
+func trimTrailingSpace(s string) string {
+	for strings.HasSuffix(s, " ") && !strings.HasSuffix(s, `\ `) {
+		s = s[:len(s)-1]
+	}
+	return strings.TrimRight(s, "\r")
+}
+
+// translate converts a gitignore glob into a regular expression.
+func translate(p string) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(p); i++ {
+		c := p[i]
+		switch c {
+		case '*':
+			if i+1 < len(p) && p[i+1] == '*' {
+				atStart := i == 0 || p[i-1] == '/'
+				atEnd := i+2 == len(p) || p[i+2] == '/'
+				if atStart && atEnd {
+					switch {
+					case i+2 == len(p):
+						// trailing "/**" (or a lone "**") matches everything inside
+						b.WriteString(".*")
+					default:
+						// "**/" matches zero or more directories
+						b.WriteString("(?:.*/)?")
+						i++ // skip the slash after **
+					}
+					i++
+					continue
+				}
+			}
+			b.WriteString("[^/]*")
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(p[i+1:], ']')
+			if end < 0 {
+				b.WriteString(`\[`)
+				continue
+			}
+			class := p[i+1 : i+1+end]
+			if end == 0 {
+				// "[]...]" includes a literal ]
+				next := strings.IndexByte(p[i+2:], ']')
+				if next < 0 {
+					b.WriteString(`\[`)
+					continue
+				}
+				class = p[i+1 : i+2+next]
+				end = next + 1
+			}
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			class = strings.ReplaceAll(class, `\`, `\\`)
+			if _, err := regexp.Compile("[" + class + "]"); err != nil {
+				return "", err
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		case '\\':
+			if i+1 < len(p) {
+				i++
+				b.WriteString(regexp.QuoteMeta(string(p[i])))
+			}
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	return b.String(), nil
+}
+
+// Match reports whether relPath is ignored.
+func (m *Matcher) Match(relPath string, isDir bool) bool {
+	ignored, _ := m.Explain(relPath, isDir)
+	return ignored
+}
+
+// Explain reports whether relPath is ignored and the rule that decided it,
+// which is nil when no rule matched.
+func (m *Matcher) Explain(relPath string, isDir bool) (bool, *Rule) {
+	relPath = strings.Trim(path.Clean(filepath.ToSlash(relPath)), "/")
+	if relPath == "." || relPath == "" {
+		return false, nil
+	}
+
+	// Anything inside an ignored directory is ignored.
+	parts := strings.Split(relPath, "/")
+	for i := 1; i < len(parts); i++ {
+		if ignored, rule := m.matchOne(strings.Join(parts[:i], "/"), true); ignored {
+			return true, rule
+		}
+	}
+	return m.matchOne(relPath, isDir)
+}
+
+// matchOne applies the rules to a single path; the last matching rule wins.
+func (m *Matcher) matchOne(relPath string, isDir bool) (bool, *Rule) {
+	var last *Rule
+	for _, rule := range m.rules {
+		sub := relPath
+		if rule.base != "" {
+			if !strings.HasPrefix(relPath, rule.base+"/") {
+				continue
+			}
+			sub = relPath[len(rule.base)+1:]
+		}
+		if rule.dirOnly && !isDir {
+			continue
+		}
+		if rule.re.MatchString(sub) {
+			last = rule
+		}
+	}
+	if last == nil {
+		return false, nil
+	}
+	return !last.negate, last
+}
+
+// Walk calls fn for every file under root that is not ignored, with its path
+// relative to root. Ignored directories are not descended into.
+func (m *Matcher) Walk(root string, fn func(relPath string, d fs.DirEntry) error) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		if rel == "." {
+			return nil
+		}
+		rel = filepath.ToSlash(rel)
+		if m.Match(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		return fn(rel, d)
+	})
+}
@@ -0,0 +1,194 @@
+package ignore
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name     string
+		patterns []string
+		path     string
+		isDir    bool
+		want     bool
+	}{
+		{name: "no rules", patterns: nil, path: "a.py", want: false},
+		{name: "comment and blank", patterns: []string{"# a.py", "", "   "}, path: "a.py", want: false},
+		{name: "basename anywhere", patterns: []string{"*.pyc"}, path: "pkg/sub/a.pyc", want: true},
+		{name: "star stays in a segment", patterns: []string{"a*"}, path: "ab/c", want: true},
+		{name: "question mark", patterns: []string{"a?.txt"}, path: "ab.txt", want: true},
+		{name: "question mark not slash", patterns: []string{"a?b"}, path: "a/b", want: false},
+		{name: "character class", patterns: []string{"[ab].txt"}, path: "b.txt", want: true},
+		{name: "negated class", patterns: []string{"[!ab].txt"}, path: "a.txt", want: false},
+		{name: "unclosed class is literal", patterns: []string{"[ab"}, path: "[ab", want: true},
+		{name: "escaped star", patterns: []string{`\*.txt`}, path: "a.txt", want: false},
+		{name: "escaped star literal", patterns: []string{`\*.txt`}, path: "*.txt", want: true},
+		{name: "escaped hash", patterns: []string{`\#notes`}, path: "#notes", want: true},
+		{name: "trailing spaces trimmed", patterns: []string{"a.txt   "}, path: "a.txt", want: true},
+		{name: "escaped trailing space kept", patterns: []string{`a\ `}, path: "a ", want: true},
+
+		// anchoring
+		{name: "leading slash anchors", patterns: []string{"/build"}, path: "build", isDir: true, want: true},
+		{name: "leading slash anchors to root", patterns: []string{"/build"}, path: "src/build", isDir: true, want: false},
+		{name: "inner slash anchors", patterns: []string{"docs/*.md"}, path: "docs/a.md", want: true},
+		{name: "inner slash anchors to root", patterns: []string{"docs/*.md"}, path: "x/docs/a.md", want: false},
+		{name: "unanchored matches at any depth", patterns: []string{"build"}, path: "src/build", isDir: true, want: true},
+
+		// **
+		{name: "leading ** any depth", patterns: []string{"**/logs"}, path: "a/b/logs", isDir: true, want: true},
+		{name: "leading ** zero depth", patterns: []string{"**/logs"}, path: "logs", isDir: true, want: true},
+		{name: "trailing ** inside", patterns: []string{"data/**"}, path: "data/x/y.csv", want: true},
+		{name: "trailing ** not the dir itself", patterns: []string{"data/**"}, path: "data", isDir: true, want: false},
+		{name: "middle ** zero dirs", patterns: []string{"a/**/b"}, path: "a/b", want: true},
+		{name: "middle ** several dirs", patterns: []string{"a/**/b"}, path: "a/x/y/b", want: true},
+		{name: "** inside a segment is a star", patterns: []string{"a**b"}, path: "a/b", want: false},
+
+		// directories only
+		{name: "dir-only matches dir", patterns: []string{"cache/"}, path: "cache", isDir: true, want: true},
+		{name: "dir-only skips file", patterns: []string{"cache/"}, path: "cache", want: false},
+		{name: "dir-only ignores contents", patterns: []string{"cache/"}, path: "cache/a.bin", want: true},
+		{name: "dir-only nested", patterns: []string{"cache/"}, path: "x/cache/a.bin", want: true},
+
+		// negation
+		{name: "negation re-includes", patterns: []string{"*.log", "!keep.log"}, path: "keep.log", want: false},
+		{name: "negation leaves others", patterns: []string{"*.log", "!keep.log"}, path: "other.log", want: true},
+		{name: "last rule wins", patterns: []string{"!keep.log", "*.log"}, path: "keep.log", want: true},
+		{name: "escaped bang is literal", patterns: []string{`\!important`}, path: "!important", want: true},
+		{name: "no re-include inside ignored dir", patterns: []string{"build/", "!build/keep.txt"}, path: "build/keep.txt", want: true},
+		{name: "re-include with dir contents pattern", patterns: []string{"build/*", "!build/keep.txt"}, path: "build/keep.txt", want: false},
+		{name: "contents pattern ignores siblings", patterns: []string{"build/*", "!build/keep.txt"}, path: "build/other.txt", want: true},
+
+		// paths are cleaned
+		{name: "dot slash prefix", patterns: []string{"/a.txt"}, path: "./a.txt", want: true},
+		{name: "root is never ignored", patterns: []string{"*"}, path: ".", isDir: true, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := New(tt.patterns)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := m.Match(tt.path, tt.isDir); got != tt.want {
+				t.Errorf("patterns %q: Match(%q, %v) = %v, want %v", tt.patterns, tt.path, tt.isDir, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewInvalidPattern(t *testing.T) {
+	if _, err := New([]string{"[z-a]"}); err == nil {
+		t.Error("expected an error for an invalid character range")
+	}
+}
+
+func TestExplain(t *testing.T) {
+	m, err := New([]string{"*.log", "!keep.log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Add("--exclude", []string{"tmp/"}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path    string
+		ignored bool
+		rule    string
+	}{
+		{"a.log", true, `default pattern "*.log"`},
+		{"keep.log", false, `default pattern "!keep.log"`},
+		{"tmp/a.txt", true, "--exclude: tmp/"},
+		{"a.txt", false, ""},
+	}
+	for _, tt := range tests {
+		ignored, rule := m.Explain(tt.path, false)
+		got := ""
+		if rule != nil {
+			got = rule.String()
+		}
+		if ignored != tt.ignored || got != tt.rule {
+			t.Errorf("Explain(%q) = %v, %q; want %v, %q", tt.path, ignored, got, tt.ignored, tt.rule)
+		}
+	}
+}
+
+func TestWithLeavesOriginal(t *testing.T) {
+	m, err := New([]string{"*.log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := m.With("--include", []string{"!keep.log"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !m.Match("keep.log", false) {
+		t.Error("With changed the original matcher")
+	}
+	if c.Match("keep.log", false) {
+		t.Error("With did not add the patterns to the copy")
+	}
+}
+
+func TestLiteral(t *testing.T) {
+	for _, name := range []string{"plain.txt", "dir/a b.txt", "we*ird?[1].txt", `back\slash`, "trailing "} {
+		m, err := New([]string{Literal(name)})
+		if err != nil {
+			t.Fatalf("Literal(%q) = %q: %v", name, Literal(name), err)
+		}
+		if !m.Match(name, false) {
+			t.Errorf("Literal(%q) = %q does not match it", name, Literal(name))
+		}
+		if m.Match("x/"+name, false) {
+			t.Errorf("Literal(%q) = %q matches it in a subdirectory", name, Literal(name))
+		}
+	}
+	m, _ := New([]string{Literal("a*.txt")})
+	if m.Match("abc.txt", false) {
+		t.Error(`Literal("a*.txt") matches abc.txt`)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".runpodignore":         "*.tmp\nignored/\n",
+		"a.py":                  "",
+		"a.tmp":                 "",
+		"src/.runpodignore":     "!keep.tmp\n/local.txt\n",
+		"src/keep.tmp":          "",
+		"src/other.tmp":         "",
+		"src/local.txt":         "",
+		"src/deep/local.txt":    "",
+		"ignored/.runpodignore": "!*\n",
+		"ignored/a.py":          "",
+	}
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	m, err := Load(root, ".runpodignore", []string{".git/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var kept []string
+	err = m.Walk(root, func(rel string, d os.DirEntry) error {
+		if !d.IsDir() {
+			kept = append(kept, rel)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Walk visits in lexical order
+	want := []string{".runpodignore", "a.py", "src/.runpodignore", "src/deep/local.txt", "src/keep.tmp"}
+	if !reflect.DeepEqual(kept, want) {
+		t.Errorf("kept %q, want %q", kept, want)
+	}
+}